@@ -6,6 +6,7 @@ import (
 	"passontw-slot-game/internal/handler"
 	"passontw-slot-game/internal/pkg/database"
 	"passontw-slot-game/internal/pkg/logger"
+	"passontw-slot-game/internal/pkg/sms"
 	"passontw-slot-game/internal/service"
 
 	_ "passontw-slot-game/docs" // 導入 swagger docs
@@ -44,10 +45,22 @@ func main() {
 			config.NewConfig,
 			logger.NewLogger,
 			database.NewDatabase,
+			service.NewPaytableService,
+			service.NewWalletService,
+			service.NewCurrencyService,
+			service.NewReportService,
 			service.NewGameService,
 			service.NewHelloService,
-			service.NewAuthService,
+			service.NewTokenService,
+			sms.NewSender,
+			service.NewOTPService,
+			service.NewAuditService,
+			service.NewLoginGuard,
+			service.NewTwoFactorService,
 			service.NewCheckerService,
+			service.NewAutoplayService,
+			service.NewGameConfigService,
+			service.NewOperatorService,
 			fx.Annotate(
 				service.NewUserService,
 				fx.As(new(service.UserService)),
@@ -56,6 +69,13 @@ func main() {
 			handler.NewGameHandler,
 			handler.NewAuthHandler,
 			handler.NewUserHandler,
+			handler.NewAutoplayHandler,
+			handler.NewGameConfigHandler,
+			handler.NewWalletHandler,
+			handler.NewCurrencyHandler,
+			handler.NewOperatorHandler,
+			handler.NewAdminHandler,
+			handler.NewTwoFactorHandler,
 			handler.NewWebSocketHandler,
 			handler.NewRouter,
 		),
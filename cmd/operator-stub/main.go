@@ -0,0 +1,202 @@
+// operator-stub 是模擬營運商錢包 API 的本機伺服器，用於測試營運商的外部錢包
+// 餘額只保存在記憶體中，重新啟動後清空
+// STUB_WALLET_SECRET 需與管理介面中為營運商設定的錢包密鑰相同
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/pkg/seamless"
+	"passontw-slot-game/pkg/money"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// transactionResult 已處理交易的結果，重複請求返回相同內容
+type transactionResult struct {
+	status int
+	body   interface{}
+}
+
+type operator struct {
+	secret         string
+	initialBalance string
+	failureRate    int // 模擬 5xx 錯誤的機率（百分比），用於測試重試
+
+	mu           sync.Mutex
+	balances     map[string]map[money.Currency]money.Money
+	transactions map[string]transactionResult
+	debits       map[string]seamless.TransactionRequest
+}
+
+func main() {
+	port := config.GetEnv("STUB_PORT", "4000")
+	failureRate, _ := strconv.Atoi(config.GetEnv("STUB_FAILURE_RATE", "0"))
+
+	op := &operator{
+		secret:         config.GetEnv("STUB_WALLET_SECRET", "your_operator_secret"),
+		initialBalance: config.GetEnv("STUB_INITIAL_BALANCE", "1000"),
+		failureRate:    failureRate,
+		balances:       make(map[string]map[money.Currency]money.Money),
+		transactions:   make(map[string]transactionResult),
+		debits:         make(map[string]seamless.TransactionRequest),
+	}
+
+	router := gin.Default()
+	router.Use(op.verifySignature, op.injectFailures)
+	router.POST(seamless.PathBalance, op.balance)
+	router.POST(seamless.PathDebit, op.debit)
+	router.POST(seamless.PathCredit, op.credit)
+	router.POST(seamless.PathRollback, op.rollback)
+
+	log.Printf("Operator wallet stub listening on :%s", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// verifySignature 驗證請求的 HMAC 簽章，並保留請求內容供後續解析
+func (op *operator) verifySignature(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		abort(c, http.StatusBadRequest, seamless.CodeInvalidRequest, "unreadable body")
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	timestamp := c.GetHeader(seamless.HeaderTimestamp)
+	signature := c.GetHeader(seamless.HeaderSignature)
+	if !seamless.Verify(op.secret, timestamp, signature, body, time.Now()) {
+		abort(c, http.StatusUnauthorized, seamless.CodeInvalidSignature, "invalid signature")
+		return
+	}
+	c.Next()
+}
+
+// injectFailures 依設定的機率返回 503
+func (op *operator) injectFailures(c *gin.Context) {
+	if op.failureRate > 0 && rand.Intn(100) < op.failureRate {
+		abort(c, http.StatusServiceUnavailable, "UNAVAILABLE", "simulated failure")
+		return
+	}
+	c.Next()
+}
+
+func (op *operator) balance(c *gin.Context) {
+	var req seamless.BalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.PlayerID == "" {
+		abort(c, http.StatusBadRequest, seamless.CodeInvalidRequest, "invalid request")
+		return
+	}
+
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	var balances []seamless.Balance
+	if req.Currency != "" {
+		balances = append(balances, toBalance(op.wallet(req.PlayerID, money.Currency(req.Currency))))
+	} else {
+		for _, amount := range op.balances[req.PlayerID] {
+			balances = append(balances, toBalance(amount))
+		}
+	}
+	c.JSON(http.StatusOK, seamless.BalanceResponse{Balances: balances})
+}
+
+func (op *operator) debit(c *gin.Context) {
+	op.handleTransaction(c, func(req seamless.TransactionRequest, amount money.Money) transactionResult {
+		balance := op.wallet(req.PlayerID, amount.Currency())
+		if balance.Cmp(amount) < 0 {
+			return transactionResult{http.StatusPaymentRequired, seamless.ErrorResponse{Code: seamless.CodeInsufficientFunds, Message: "insufficient funds"}}
+		}
+		op.debits[req.TransactionID] = req
+		return op.update(req.PlayerID, balance.Sub(amount))
+	})
+}
+
+func (op *operator) credit(c *gin.Context) {
+	op.handleTransaction(c, func(req seamless.TransactionRequest, amount money.Money) transactionResult {
+		balance := op.wallet(req.PlayerID, amount.Currency())
+		return op.update(req.PlayerID, balance.Add(amount))
+	})
+}
+
+func (op *operator) rollback(c *gin.Context) {
+	op.handleTransaction(c, func(req seamless.TransactionRequest, _ money.Money) transactionResult {
+		debit, ok := op.debits[req.TransactionID]
+		if !ok {
+			return transactionResult{http.StatusNotFound, seamless.ErrorResponse{Code: seamless.CodeTransactionNotFound, Message: "debit not found"}}
+		}
+		delete(op.debits, req.TransactionID)
+
+		amount, _ := money.Parse(debit.Amount, money.Currency(debit.Currency))
+		balance := op.wallet(debit.PlayerID, amount.Currency())
+		return op.update(debit.PlayerID, balance.Add(amount))
+	})
+}
+
+// handleTransaction 解析交易請求，相同交易 ID 的重複請求直接返回先前的結果
+func (op *operator) handleTransaction(c *gin.Context, apply func(req seamless.TransactionRequest, amount money.Money) transactionResult) {
+	var req seamless.TransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.TransactionID == "" || req.PlayerID == "" {
+		abort(c, http.StatusBadRequest, seamless.CodeInvalidRequest, "invalid request")
+		return
+	}
+	amount, err := money.Parse(req.Amount, money.Currency(req.Currency))
+	if err != nil || amount.IsNegative() {
+		abort(c, http.StatusBadRequest, seamless.CodeInvalidRequest, "invalid amount")
+		return
+	}
+
+	key := c.FullPath() + ":" + req.TransactionID
+
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	result, ok := op.transactions[key]
+	if !ok {
+		result = apply(req, amount)
+		op.transactions[key] = result
+	}
+
+	data, _ := json.Marshal(result.body)
+	c.Data(result.status, "application/json", data)
+}
+
+// wallet 返回玩家的餘額，第一次使用該貨幣時給予初始餘額
+func (op *operator) wallet(playerID string, currency money.Currency) money.Money {
+	if op.balances[playerID] == nil {
+		op.balances[playerID] = make(map[money.Currency]money.Money)
+	}
+	balance, ok := op.balances[playerID][currency]
+	if !ok {
+		initial, err := money.Parse(op.initialBalance, currency)
+		if err != nil {
+			initial = money.Zero(currency)
+		}
+		balance = initial
+		op.balances[playerID][currency] = balance
+	}
+	return balance
+}
+
+func (op *operator) update(playerID string, balance money.Money) transactionResult {
+	op.balances[playerID][balance.Currency()] = balance
+	return transactionResult{http.StatusOK, seamless.BalanceResponse{Balances: []seamless.Balance{toBalance(balance)}}}
+}
+
+func toBalance(amount money.Money) seamless.Balance {
+	return seamless.Balance{Currency: string(amount.Currency()), Amount: amount.String()}
+}
+
+func abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, seamless.ErrorResponse{Code: code, Message: message})
+}
@@ -3,9 +3,18 @@ package config
 import "fmt"
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Game      GameConfig
+	Currency  CurrencyConfig
+	Wallet    WalletConfig
+	Operator  OperatorConfig
+	SMS       SMSConfig
+	OTP       OTPConfig
+	Login     LoginConfig
+	TwoFactor TwoFactorConfig
+	Admin     AdminConfig
 }
 
 type DatabaseConfig struct {
@@ -21,7 +30,8 @@ func NewConfig() *Config {
 
 	return &Config{
 		Server: ServerConfig{
-			Port: fmt.Sprintf(":%s", envConfig.Server.Port),
+			Port:           fmt.Sprintf(":%s", envConfig.Server.Port),
+			TrustedProxies: envConfig.Server.TrustedProxies,
 		},
 		Database: DatabaseConfig{
 			Host:     envConfig.Database.Host,
@@ -31,8 +41,19 @@ func NewConfig() *Config {
 			Password: envConfig.Database.Password,
 		},
 		JWT: JWTConfig{
-			Secret:    envConfig.JWT.Secret,
-			ExpiresIn: envConfig.JWT.ExpiresIn,
+			SigningKeyFile:   envConfig.JWT.SigningKeyFile,
+			VerifyKeyFiles:   envConfig.JWT.VerifyKeyFiles,
+			ExpiresIn:        envConfig.JWT.ExpiresIn,
+			RefreshExpiresIn: envConfig.JWT.RefreshExpiresIn,
 		},
+		Game:      envConfig.Game,
+		Currency:  envConfig.Currency,
+		Wallet:    envConfig.Wallet,
+		Operator:  envConfig.Operator,
+		SMS:       envConfig.SMS,
+		OTP:       envConfig.OTP,
+		Login:     envConfig.Login,
+		TwoFactor: envConfig.TwoFactor,
+		Admin:     envConfig.Admin,
 	}
 }
@@ -4,24 +4,113 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type ServerConfig struct {
-	Port string
+	Port           string
+	TrustedProxies []string // 可信任的反向代理 IP 或 CIDR，只有來自這些位址的 X-Forwarded-For 會被採用
 }
 
 type EnvConfig struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Game      GameConfig
+	Currency  CurrencyConfig
+	Wallet    WalletConfig
+	Operator  OperatorConfig
+	SMS       SMSConfig
+	OTP       OTPConfig
+	Login     LoginConfig
+	TwoFactor TwoFactorConfig
+	Admin     AdminConfig
 }
 
 type JWTConfig struct {
-	Secret    string
-	ExpiresIn time.Duration
+	SigningKeyFile   string        // 簽發 token 的 RSA 或 Ed25519 私鑰 (PEM)
+	VerifyKeyFiles   []string      // 輪替期間仍接受的舊公鑰 (PEM)
+	ExpiresIn        time.Duration // access token 有效期限
+	RefreshExpiresIn time.Duration // refresh token 有效期限
+}
+
+type GameConfig struct {
+	WildModifiers   []string // 啟用的百搭效果: sticky, expanding, walking
+	FreeSpinTrigger int      // 觸發免費旋轉所需的百搭數量
+	FreeSpins       int      // 每次觸發獲得的免費旋轉次數
+
+	HoldAndSpinTrigger int // 觸發 Hold and Spin 所需的金幣數量
+	HoldAndSpinRespins int // Hold and Spin 的重轉次數，落下新金幣時重置
+
+	FruitMachine    bool // 啟用經典水果機模式（使用輪帶，未中獎時可能給予 Hold 或 Nudge）
+	NudgeHoldChance int  // 未中獎時給予 Hold 或 Nudge 的機率（百分比）
+	MaxNudges       int  // 單次最多給予的 Nudge 次數
+
+	MaxWinMultiplier       float64 // 單局最高獎金倍數（以下注金額計），0 代表不限制
+	MaxRoundLiability      string  // 單局最高派彩金額（十進位字串，以基準貨幣計，依匯率換算為下注貨幣），0 代表不限制
+	MaxWinIncludesJackpots bool    // 最高獎金是否包含彩金
+
+	RoundTimeout time.Duration // 中斷的遊戲局超過此時間未有動作時自動結算，0 代表不自動結算
+
+	DefinitionsDir string  // 遊戲定義檔案目錄，啟動時登記為修訂版
+	MinRTP         float64 // 發布遊戲定義時允許的最低主遊戲理論返還率
+	MaxRTP         float64 // 發布遊戲定義時允許的最高主遊戲理論返還率
+}
+
+type CurrencyConfig struct {
+	Default string // 請求未指定貨幣時使用的貨幣代碼
+	Base    string // 報表彙總使用的基準貨幣，匯率以此貨幣計價
+}
+
+// WalletConfig 營運商外部錢包的請求設定，各營運商的錢包網址及簽章密鑰保存在營運商資料
+type WalletConfig struct {
+	Timeout time.Duration // 單次請求逾時
+	Retries int           // 網路錯誤或 5xx 時的重試次數
+}
+
+type OperatorConfig struct {
+	SessionTTL time.Duration // 營運商建立的遊戲 session token 有效時間
+	LaunchURL  string        // 遊戲前端的網址，啟動網址會附加 token 等參數
+}
+
+// 簡訊服務
+const (
+	SMSProviderConsole = "console" // 將簡訊內容輸出到 log，僅供本機開發使用
+)
+
+type SMSConfig struct {
+	Provider string // 簡訊服務供應商
+}
+
+type OTPConfig struct {
+	TTL             time.Duration // 驗證碼有效時間
+	ResendInterval  time.Duration // 同一電話重新發送驗證碼的最短間隔
+	HourlyLimit     int           // 同一電話每小時最多發送次數
+	MaxAttempts     int           // 單一驗證碼可嘗試的次數
+	VerificationTTL time.Duration // 驗證通過後完成註冊的期限
+}
+
+type LoginConfig struct {
+	FailureWindow time.Duration // 超過此時間沒有失敗時重新計算失敗次數
+	DelayAfter    int           // 同一電話連續失敗幾次後開始要求等待
+	DelayBase     time.Duration // 第一次等待的時間，之後每次失敗加倍
+	MaxDelay      time.Duration // 等待時間上限
+	MaxFailures   int           // 同一電話連續失敗幾次後鎖定
+	IPMaxFailures int           // 同一 IP 連續失敗幾次後鎖定
+	LockoutTTL    time.Duration // 鎖定時間
+}
+
+type TwoFactorConfig struct {
+	Issuer       string        // 驗證器 App 中顯示的服務名稱
+	ChallengeTTL time.Duration // 密碼正確後輸入驗證碼或完成設定的期限
+}
+
+type AdminConfig struct {
+	Phone    string // 啟動時設為管理者的平台使用者電話，用於建立第一個管理者
+	Password string // 該電話尚未註冊時建立使用者使用的密碼
 }
 
 func LoadEnv() *EnvConfig {
@@ -32,7 +121,8 @@ func LoadEnv() *EnvConfig {
 
 	config := &EnvConfig{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "3000"),
+			Port:           getEnv("PORT", "3000"),
+			TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", ""),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -42,11 +132,82 @@ func LoadEnv() *EnvConfig {
 			Password: getEnv("DB_PASSWORD", ""),
 		},
 		JWT: JWTConfig{
-			Secret:    getEnv("JWT_SECRET", "default-secret-key"),
-			ExpiresIn: getEnvAsDuration("JWT_EXPIRES_IN", "24h"),
+			SigningKeyFile:   getEnv("JWT_SIGNING_KEY_FILE", ""),
+			VerifyKeyFiles:   getEnvAsSlice("JWT_VERIFY_KEY_FILES", ""),
+			ExpiresIn:        getEnvAsDuration("JWT_EXPIRES_IN", "15m"),
+			RefreshExpiresIn: getEnvAsDuration("JWT_REFRESH_EXPIRES_IN", "720h"),
+		},
+		Game: GameConfig{
+			WildModifiers:   getEnvAsSlice("GAME_WILD_MODIFIERS", "sticky"),
+			FreeSpinTrigger: getEnvAsInt("GAME_FREE_SPIN_TRIGGER", 3),
+			FreeSpins:       getEnvAsInt("GAME_FREE_SPINS", 5),
+
+			HoldAndSpinTrigger: getEnvAsInt("GAME_HOLD_AND_SPIN_TRIGGER", 5),
+			HoldAndSpinRespins: getEnvAsInt("GAME_HOLD_AND_SPIN_RESPINS", 3),
+
+			FruitMachine:    getEnvAsBool("GAME_FRUIT_MACHINE", false),
+			NudgeHoldChance: getEnvAsInt("GAME_NUDGE_HOLD_CHANCE", 30),
+			MaxNudges:       getEnvAsInt("GAME_MAX_NUDGES", 3),
+
+			MaxWinMultiplier:       getEnvAsFloat("GAME_MAX_WIN_MULTIPLIER", 5000),
+			MaxRoundLiability:      getEnv("GAME_MAX_ROUND_LIABILITY", "1000000"),
+			MaxWinIncludesJackpots: getEnvAsBool("GAME_MAX_WIN_INCLUDES_JACKPOTS", false),
+
+			RoundTimeout: getEnvAsDuration("GAME_ROUND_TIMEOUT", "24h"),
+
+			DefinitionsDir: getEnv("GAME_DEFINITIONS_DIR", ""),
+			MinRTP:         getEnvAsFloat("GAME_RTP_MIN", 0.40),
+			MaxRTP:         getEnvAsFloat("GAME_RTP_MAX", 0.98),
 		},
 	}
 
+	config.Currency = CurrencyConfig{
+		Default: strings.ToUpper(getEnv("DEFAULT_CURRENCY", "TWD")),
+		Base:    strings.ToUpper(getEnv("BASE_CURRENCY", "TWD")),
+	}
+
+	config.Wallet = WalletConfig{
+		Timeout: getEnvAsDuration("OPERATOR_WALLET_TIMEOUT", "5s"),
+		Retries: getEnvAsInt("OPERATOR_WALLET_RETRIES", 3),
+	}
+
+	config.Operator = OperatorConfig{
+		SessionTTL: getEnvAsDuration("OPERATOR_SESSION_TTL", "15m"),
+		LaunchURL:  getEnv("GAME_LAUNCH_URL", "http://localhost:8080/play"),
+	}
+
+	config.SMS = SMSConfig{
+		Provider: strings.ToLower(getEnv("SMS_PROVIDER", SMSProviderConsole)),
+	}
+
+	config.OTP = OTPConfig{
+		TTL:             getEnvAsDuration("OTP_TTL", "5m"),
+		ResendInterval:  getEnvAsDuration("OTP_RESEND_INTERVAL", "60s"),
+		HourlyLimit:     getEnvAsInt("OTP_HOURLY_LIMIT", 5),
+		MaxAttempts:     getEnvAsInt("OTP_MAX_ATTEMPTS", 5),
+		VerificationTTL: getEnvAsDuration("OTP_VERIFICATION_TTL", "15m"),
+	}
+
+	config.Login = LoginConfig{
+		FailureWindow: getEnvAsDuration("LOGIN_FAILURE_WINDOW", "15m"),
+		DelayAfter:    getEnvAsInt("LOGIN_DELAY_AFTER", 3),
+		DelayBase:     getEnvAsDuration("LOGIN_DELAY_BASE", "1s"),
+		MaxDelay:      getEnvAsDuration("LOGIN_MAX_DELAY", "30s"),
+		MaxFailures:   getEnvAsInt("LOGIN_MAX_FAILURES", 10),
+		IPMaxFailures: getEnvAsInt("LOGIN_IP_MAX_FAILURES", 50),
+		LockoutTTL:    getEnvAsDuration("LOGIN_LOCKOUT_TTL", "15m"),
+	}
+
+	config.TwoFactor = TwoFactorConfig{
+		Issuer:       getEnv("TOTP_ISSUER", "Passontw Slot Game"),
+		ChallengeTTL: getEnvAsDuration("TWO_FACTOR_CHALLENGE_TTL", "5m"),
+	}
+
+	config.Admin = AdminConfig{
+		Phone:    getEnv("ADMIN_PHONE", ""),
+		Password: getEnv("ADMIN_PASSWORD", ""),
+	}
+
 	// 驗證必要的環境變數
 	validateEnvConfig(config)
 
@@ -69,6 +230,24 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key, defaultValue string) time.Duration {
 	value := getEnv(key, defaultValue)
 	duration, err := time.ParseDuration(value)
@@ -79,13 +258,23 @@ func getEnvAsDuration(key, defaultValue string) time.Duration {
 	return duration
 }
 
+func getEnvAsSlice(key, defaultValue string) []string {
+	var result []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func validateEnvConfig(config *EnvConfig) {
 	// 檢查必要的配置
 	if config.Database.Password == "" {
 		log.Fatal("DB_PASSWORD is required")
 	}
-	if config.JWT.Secret == "default-secret-key" {
-		log.Print("Warning: using default JWT secret key, this is not recommended for production")
+	if config.JWT.SigningKeyFile == "" {
+		log.Print("Warning: JWT_SIGNING_KEY_FILE is not set, tokens are signed with a temporary key and become invalid on restart")
 	}
 }
 
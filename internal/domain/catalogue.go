@@ -0,0 +1,71 @@
+package domain
+
+// DefaultGameID 目前唯一的遊戲
+const DefaultGameID = "classic"
+
+// SymbolType 代表符號的類型
+type SymbolType string
+
+const (
+	SymbolRegular SymbolType = "regular"
+	SymbolWild    SymbolType = "wild"
+	SymbolScatter SymbolType = "scatter"
+	SymbolCoin    SymbolType = "coin"
+)
+
+// SymbolMeta 儲存符號的顯示資訊，供前端渲染使用
+type SymbolMeta struct {
+	ID       int               `json:"id" example:"0"`
+	Code     string            `json:"code" example:"cherry"`
+	Names    map[string]string `json:"names"`
+	AssetKey string            `json:"assetKey" example:"symbols/cherry"`
+	Type     SymbolType        `json:"type" example:"regular"`
+}
+
+// symbolNames 各語系的符號顯示名稱
+var symbolNames = map[Symbol]map[string]string{
+	Cherry:  {"en": "Cherry", "zh-TW": "櫻桃"},
+	Bell:    {"en": "Bell", "zh-TW": "鈴鐺"},
+	Lemon:   {"en": "Lemon", "zh-TW": "檸檬"},
+	Orange:  {"en": "Orange", "zh-TW": "橘子"},
+	Star:    {"en": "Star", "zh-TW": "星星"},
+	Skull:   {"en": "Skull", "zh-TW": "骷髏"},
+	Crown:   {"en": "Crown", "zh-TW": "皇冠"},
+	Diamond: {"en": "Diamond", "zh-TW": "鑽石"},
+	Seven:   {"en": "Seven", "zh-TW": "七"},
+	BAR:     {"en": "BAR", "zh-TW": "BAR"},
+	Wild:    {"en": "Wild", "zh-TW": "百搭"},
+	Coin:    {"en": "Coin", "zh-TW": "金幣"},
+}
+
+// GetSymbolCatalogue 返回指定遊戲的符號目錄
+func GetSymbolCatalogue(gameID string) ([]SymbolMeta, bool) {
+	if gameID != DefaultGameID {
+		return nil, false
+	}
+
+	symbols := GetSymbolList()
+	catalogue := make([]SymbolMeta, 0, len(symbols))
+	for _, info := range symbols {
+		catalogue = append(catalogue, SymbolMeta{
+			ID:       int(info.Symbol),
+			Code:     info.Symbol.Code(),
+			Names:    symbolNames[info.Symbol],
+			AssetKey: "symbols/" + info.Symbol.Code(),
+			Type:     info.Symbol.Type(),
+		})
+	}
+	return catalogue, true
+}
+
+// Type 返回符號的類型
+func (s Symbol) Type() SymbolType {
+	switch s {
+	case Wild:
+		return SymbolWild
+	case Coin:
+		return SymbolCoin
+	default:
+		return SymbolRegular
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+// Jackpot 代表金幣上的彩金類型
+type Jackpot string
+
+const (
+	JackpotNone  Jackpot = ""
+	JackpotMini  Jackpot = "mini"
+	JackpotMajor Jackpot = "major"
+)
+
+// CoinValue 儲存金幣符號的面額資訊
+type CoinValue struct {
+	Value   float64 `json:"value"`             // 獎金倍數（以下注金額計）
+	Jackpot Jackpot `json:"jackpot,omitempty"` // 彩金類型
+	Weight  int     `json:"weight,omitempty"`  // 出現權重
+}
+
+// GetCoinValueList 返回所有金幣面額的配置信息
+func GetCoinValueList() []CoinValue {
+	return []CoinValue{
+		{1, JackpotNone, 30}, // 較常見，面額較小
+		{2, JackpotNone, 25},
+		{3, JackpotNone, 15},
+		{5, JackpotNone, 12},
+		{10, JackpotNone, 8},
+		{20, JackpotMini, 4},   // Mini 彩金
+		{100, JackpotMajor, 1}, // Major 彩金，最稀有
+	}
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// GameDefinition 代表一個遊戲的數學模型，例如符號權重、賠付及金幣面額
+type GameDefinition struct {
+	GameID     string       `json:"gameId"`
+	Symbols    []SymbolInfo `json:"symbols"`
+	CoinValues []CoinValue  `json:"coinValues"`
+}
+
+// DefaultGameDefinition 返回內建的遊戲定義
+func DefaultGameDefinition() GameDefinition {
+	return GameDefinition{
+		GameID:     DefaultGameID,
+		Symbols:    GetSymbolList(),
+		CoinValues: GetCoinValueList(),
+	}
+}
+
+// Hash 計算遊戲定義內容的 SHA-256 雜湊，作為不可變修訂版的識別碼
+func (d GameDefinition) Hash() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// paylineCount 盤面上的連線數量：3 條橫線、3 條直線及 2 條對角線
+const paylineCount = 8
+
+// Validate 檢查遊戲定義是否一致，返回所有錯誤訊息
+func (d GameDefinition) Validate() []string {
+	var errs []string
+	if d.GameID == "" {
+		errs = append(errs, "gameId is required")
+	}
+	if len(d.Symbols) == 0 {
+		errs = append(errs, "at least one symbol is required")
+	}
+
+	seen := make(map[Symbol]bool)
+	paying := 0
+	for _, info := range d.Symbols {
+		code := info.Symbol.Code()
+		if _, ok := symbolNames[info.Symbol]; !ok {
+			errs = append(errs, fmt.Sprintf("symbol %d does not exist", int(info.Symbol)))
+			continue
+		}
+		if seen[info.Symbol] {
+			errs = append(errs, fmt.Sprintf("symbol %s is defined more than once", code))
+		}
+		seen[info.Symbol] = true
+
+		if info.Weight <= 0 {
+			errs = append(errs, fmt.Sprintf("symbol %s must have a positive weight", code))
+		}
+		if info.Payout < 0 {
+			errs = append(errs, fmt.Sprintf("symbol %s must not have a negative payout", code))
+		}
+		if info.Symbol == Coin && info.Payout != 0 {
+			errs = append(errs, "coin symbol does not form lines and must have a zero payout")
+		}
+		if info.Symbol.Type() == SymbolRegular && info.Payout > 0 {
+			paying++
+		}
+	}
+	if len(d.Symbols) > 0 && paying == 0 {
+		errs = append(errs, "at least one regular symbol must pay")
+	}
+
+	// 百搭可替代任何符號，因此三個百搭的賠付不應低於任何一般符號
+	if wild, ok := d.symbol(Wild); ok {
+		for _, info := range d.Symbols {
+			if info.Symbol.Type() == SymbolRegular && info.Payout > wild.Payout {
+				errs = append(errs, fmt.Sprintf("wild payout must not be lower than symbol %s payout", info.Symbol.Code()))
+			}
+		}
+	}
+
+	if _, ok := d.symbol(Coin); ok && len(d.CoinValues) == 0 {
+		errs = append(errs, "coin values are required when the coin symbol is used")
+	}
+	for i, coin := range d.CoinValues {
+		if coin.Value <= 0 || coin.Weight <= 0 {
+			errs = append(errs, fmt.Sprintf("coin value #%d must have a positive value and weight", i+1))
+		}
+	}
+
+	return errs
+}
+
+// LineRTP 計算主遊戲連線的理論返還率（不含免費旋轉及 Hold and Spin）
+// 每格符號獨立依權重出現，每條線的期望值為各符號（含百搭替代）三連的機率乘以賠付
+func (d GameDefinition) LineRTP() float64 {
+	totalWeight := 0
+	for _, info := range d.Symbols {
+		totalWeight += info.Weight
+	}
+	if totalWeight <= 0 {
+		return 0
+	}
+
+	wild, _ := d.symbol(Wild)
+	pWild := float64(wild.Weight) / float64(totalWeight)
+
+	expected := wild.Payout * math.Pow(pWild, 3)
+	for _, info := range d.Symbols {
+		if info.Symbol == Wild || info.Symbol == Coin {
+			continue
+		}
+		p := float64(info.Weight) / float64(totalWeight)
+		expected += info.Payout * (math.Pow(p+pWild, 3) - math.Pow(pWild, 3))
+	}
+	return expected * paylineCount
+}
+
+func (d GameDefinition) symbol(symbol Symbol) (SymbolInfo, bool) {
+	for _, info := range d.Symbols {
+		if info.Symbol == symbol {
+			return info, true
+		}
+	}
+	return SymbolInfo{}, false
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"time"
+)
+
+// 稽核事件
+const (
+	AuditLoginLocked   = "login.locked"
+	AuditWalletDeposit = "wallet.deposit"
+	AuditWalletAdjust  = "wallet.adjust"
+	AuditUserBlock     = "user.block"
+	AuditUserUnblock   = "user.unblock"
+	AuditUserRestore   = "user.restore"
+	AuditUserRole      = "user.role"
+	AuditUserPhone     = "user.phone"
+	AuditRoundsClose   = "rounds.close"
+)
+
+// AuditLog 安全相關事件及管理操作的稽核紀錄，只新增不修改，actor_id 為執行管理操作的管理者
+// CREATE TABLE "public"."audit_logs" (
+//
+//	"id" bigserial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"user_id" int4 REFERENCES "users" ("id"),
+//	"actor_id" int4 REFERENCES "users" ("id"),
+//	"action" varchar(50) NOT NULL,
+//	"ip" varchar(45) NOT NULL DEFAULT '',
+//	"detail" jsonb NOT NULL DEFAULT '{}',
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE INDEX "idx_audit_logs_operator" ON "public"."audit_logs" ("operator_id", "created_at");
+// CREATE INDEX "idx_audit_logs_user" ON "public"."audit_logs" ("user_id");
+type AuditLog struct {
+	ID         int64     `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;default:now();index:idx_audit_logs_operator,priority:2" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID int       `gorm:"column:operator_id;not null;index:idx_audit_logs_operator,priority:1" json:"operator_id" example:"1"`
+	UserID     *int      `gorm:"column:user_id;index:idx_audit_logs_user" json:"user_id,omitempty" example:"1"`
+	ActorID    *int      `gorm:"column:actor_id" json:"actor_id,omitempty" example:"2"`
+	Action     string    `gorm:"column:action;type:varchar(50);not null" json:"action" example:"login.locked"`
+	IP         string    `gorm:"column:ip;type:varchar(45);not null;default:''" json:"ip" example:"203.0.113.7"`
+	Detail     string    `gorm:"column:detail;type:jsonb;not null;default:'{}'" json:"detail" swaggertype:"object"`
+}
+
+// TableName 指定資料表名稱
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// LoginThrottle 以電話或來源 IP 為鍵的登入失敗計數，登入成功後清除電話的計數
+// CREATE TABLE "public"."login_throttles" (
+//
+//	"key" varchar(100) NOT NULL,
+//	"failures" int4 NOT NULL DEFAULT 0,
+//	"last_failure_at" timestamp NOT NULL DEFAULT now(),
+//	"locked_until" timestamp,
+//	PRIMARY KEY ("key")
+//
+// );
+type LoginThrottle struct {
+	Key           string     `gorm:"primaryKey;column:key;type:varchar(100)" json:"key" example:"phone:1:0987654321"`
+	Failures      int        `gorm:"column:failures;not null;default:0" json:"failures" example:"3"`
+	LastFailureAt time.Time  `gorm:"column:last_failure_at;not null;default:now()" json:"last_failure_at" example:"2025-02-16T16:05:00.763995Z"`
+	LockedUntil   *time.Time `gorm:"column:locked_until" json:"locked_until,omitempty" example:"2025-02-16T16:20:00.763995Z"`
+}
+
+// TableName 指定資料表名稱
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"time"
+)
+
+// GameBetLimit 遊戲在各貨幣的下注限制資料表結構，operator_id 為 0 時是所有營運商的預設值
+// CREATE TABLE "public"."game_bet_limits" (
+//
+//	"id" serial NOT NULL,
+//	"updated_at" timestamp NOT NULL DEFAULT now(),
+//	"operator_id" int4 NOT NULL DEFAULT 0,
+//	"game_id" varchar(50) NOT NULL,
+//	"currency" varchar(10) NOT NULL,
+//	"min_bet" int8 NOT NULL,
+//	"max_bet" int8 NOT NULL,
+//	"bet_levels" jsonb NOT NULL DEFAULT '[]',
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_game_bet_limits_operator_game_currency" ON "public"."game_bet_limits" ("operator_id", "game_id", "currency");
+type GameBetLimit struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID int       `gorm:"column:operator_id;not null;default:0;uniqueIndex:idx_game_bet_limits_operator_game_currency" json:"operator_id" example:"0"`
+	GameID     string    `gorm:"column:game_id;type:varchar(50);not null;uniqueIndex:idx_game_bet_limits_operator_game_currency" json:"game_id" example:"classic"`
+	Currency   string    `gorm:"column:currency;type:varchar(10);not null;uniqueIndex:idx_game_bet_limits_operator_game_currency" json:"currency" example:"TWD"`
+	MinBet     int64     `gorm:"column:min_bet;not null" json:"min_bet" example:"100"` // 以貨幣最小單位計
+	MaxBet     int64     `gorm:"column:max_bet;not null" json:"max_bet" example:"100000"`
+	BetLevels  string    `gorm:"column:bet_levels;type:jsonb;not null;default:'[]'" json:"-"` // 允許的下注金額（最小單位），空陣列代表範圍內皆可
+}
+
+// TableName 指定資料表名稱
+func (GameBetLimit) TableName() string {
+	return "game_bet_limits"
+}
+
+// ExchangeRate 匯率資料表結構，rate 為一單位該貨幣可換得的基準貨幣數量
+// CREATE TABLE "public"."exchange_rates" (
+//
+//	"currency" varchar(10) NOT NULL,
+//	"updated_at" timestamp NOT NULL DEFAULT now(),
+//	"rate" numeric(30,12) NOT NULL,
+//	PRIMARY KEY ("currency")
+//
+// );
+type ExchangeRate struct {
+	Currency  string    `gorm:"primaryKey;column:currency;type:varchar(10)" json:"currency" example:"USD"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	Rate      string    `gorm:"column:rate;type:numeric(30,12);not null" json:"rate" example:"32.5"`
+}
+
+// TableName 指定資料表名稱
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+)
+
+// GameDraft 遊戲定義草稿資料表結構，發布後會轉為不可變的修訂版
+// CREATE TABLE "public"."game_drafts" (
+//
+//	"id" serial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"updated_at" timestamp NOT NULL DEFAULT now(),
+//	"game_id" varchar(50) NOT NULL,
+//	"status" varchar(20) NOT NULL DEFAULT 'draft',
+//	"definition" jsonb NOT NULL,
+//	"created_by" int4 NOT NULL,
+//	"revision_hash" varchar(64),
+//	"published_at" timestamp,
+//	PRIMARY KEY ("id")
+//
+// );
+type GameDraft struct {
+	ID           int        `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt    time.Time  `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	GameID       string     `gorm:"column:game_id;type:varchar(50);not null" json:"game_id" example:"classic"`
+	Status       string     `gorm:"column:status;type:varchar(20);not null;default:draft" json:"status" example:"draft"`
+	Definition   string     `gorm:"column:definition;type:jsonb;not null" json:"-"`
+	CreatedBy    int        `gorm:"column:created_by;not null" json:"created_by" example:"1"`
+	RevisionHash *string    `gorm:"column:revision_hash;type:varchar(64)" json:"revision_hash,omitempty" example:"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"`
+	PublishedAt  *time.Time `gorm:"column:published_at" json:"published_at,omitempty" example:"2025-02-16T16:05:00.763995Z"`
+}
+
+// 草稿狀態
+const (
+	DraftStatusDraft     = "draft"
+	DraftStatusPublished = "published"
+)
+
+// TableName 指定資料表名稱
+func (GameDraft) TableName() string {
+	return "game_drafts"
+}
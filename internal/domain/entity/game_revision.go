@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"time"
+)
+
+// GameRevision 遊戲定義修訂版資料表結構，以內容雜湊識別且建立後不可修改
+// CREATE TABLE "public"."game_revisions" (
+//
+//	"hash" varchar(64) NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"game_id" varchar(50) NOT NULL,
+//	"definition" jsonb NOT NULL,
+//	PRIMARY KEY ("hash")
+//
+// );
+type GameRevision struct {
+	Hash       string    `gorm:"primaryKey;column:hash;type:varchar(64)" json:"hash" example:"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	GameID     string    `gorm:"column:game_id;type:varchar(50);not null" json:"game_id" example:"classic"`
+	Definition string    `gorm:"column:definition;type:jsonb;not null" json:"-"`
+}
+
+// TableName 指定資料表名稱
+func (GameRevision) TableName() string {
+	return "game_revisions"
+}
+
+// GameRevisionActivation 遊戲定義修訂版的上線排程，rollback 標記因回溯而重新上線的紀錄
+// CREATE TABLE "public"."game_revision_activations" (
+//
+//	"id" serial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"game_id" varchar(50) NOT NULL,
+//	"revision_hash" varchar(64) NOT NULL REFERENCES "game_revisions" ("hash"),
+//	"activate_at" timestamp NOT NULL,
+//	"rollback" bool NOT NULL DEFAULT false,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE INDEX "idx_game_revision_activations_game" ON "public"."game_revision_activations" ("game_id", "activate_at");
+type GameRevisionActivation struct {
+	ID           int       `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt    time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	GameID       string    `gorm:"column:game_id;type:varchar(50);not null;index:idx_game_revision_activations_game" json:"game_id" example:"classic"`
+	RevisionHash string    `gorm:"column:revision_hash;type:varchar(64);not null" json:"revision_hash" example:"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"`
+	ActivateAt   time.Time `gorm:"column:activate_at;not null;index:idx_game_revision_activations_game" json:"activate_at" example:"2025-03-01T00:00:00Z"`
+	Rollback     bool      `gorm:"column:rollback;not null;default:false" json:"rollback" example:"false"`
+}
+
+// TableName 指定資料表名稱
+func (GameRevisionActivation) TableName() string {
+	return "game_revision_activations"
+}
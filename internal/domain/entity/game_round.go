@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"time"
+)
+
+// GameRound 遊戲局資料表結構，state 保存完整的遊戲局狀態以便斷線後恢復
+// 獎金在遊戲局提交後才派發，paid 記錄已派發的金額
+// CREATE TABLE "public"."game_rounds" (
+//
+//	"id" varchar(64) NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"updated_at" timestamp NOT NULL DEFAULT now(),
+//	"completed_at" timestamp,
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"user_id" int4 NOT NULL,
+//	"revision_hash" varchar(64) NOT NULL REFERENCES "game_revisions" ("hash"),
+//	"status" varchar(20) NOT NULL,
+//	"feature" varchar(20) NOT NULL DEFAULT '',
+//	"currency" varchar(10) NOT NULL,
+//	"bet_amount" int8 NOT NULL,
+//	"total_win" int8 NOT NULL DEFAULT 0,
+//	"paid" int8 NOT NULL DEFAULT 0,
+//	"state" jsonb NOT NULL,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE INDEX "idx_game_rounds_user_status" ON "public"."game_rounds" ("user_id", "status");
+// CREATE INDEX "idx_game_rounds_operator_completed" ON "public"."game_rounds" ("operator_id", "completed_at");
+// CREATE INDEX "idx_game_rounds_unpaid" ON "public"."game_rounds" ("updated_at") WHERE "paid" < "total_win";
+type GameRound struct {
+	ID           string     `gorm:"primaryKey;column:id;type:varchar(64)" json:"id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	CreatedAt    time.Time  `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at;not null;default:now();index:idx_game_rounds_unpaid,where:paid < total_win" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	CompletedAt  *time.Time `gorm:"column:completed_at;index:idx_game_rounds_operator_completed" json:"completed_at,omitempty" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID   int        `gorm:"column:operator_id;not null;index:idx_game_rounds_operator_completed" json:"operator_id" example:"1"`
+	UserID       int        `gorm:"column:user_id;not null;index:idx_game_rounds_user_status" json:"user_id" example:"1"`
+	RevisionHash string     `gorm:"column:revision_hash;type:varchar(64);not null" json:"revision_hash" example:"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"`
+	Status       string     `gorm:"column:status;type:varchar(20);not null;index:idx_game_rounds_user_status" json:"status" example:"open"`
+	Feature      string     `gorm:"column:feature;type:varchar(20);not null;default:''" json:"feature" example:"free_spins"`
+	Currency     string     `gorm:"column:currency;type:varchar(10);not null" json:"currency" example:"TWD"`
+	BetAmount    int64      `gorm:"column:bet_amount;not null" json:"bet_amount" example:"150"` // 以貨幣最小單位計
+	TotalWin     int64      `gorm:"column:total_win;not null;default:0" json:"total_win" example:"1050"`
+	Paid         int64      `gorm:"column:paid;not null;default:0" json:"paid" example:"1050"` // 已派發至錢包的獎金，小於 total_win 時等待重試派彩
+	State        string     `gorm:"column:state;type:jsonb;not null" json:"-"`
+}
+
+// TableName 指定資料表名稱
+func (GameRound) TableName() string {
+	return "game_rounds"
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+)
+
+// GameSpin 旋轉紀錄資料表結構
+// CREATE TABLE "public"."game_spins" (
+//
+//	"id" bigserial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"user_id" int4 NOT NULL,
+//	"round_id" varchar(64) NOT NULL,
+//	"revision_hash" varchar(64) NOT NULL REFERENCES "game_revisions" ("hash"),
+//	"idempotency_key" varchar(64),
+//	"request_hash" varchar(64) NOT NULL,
+//	"currency" varchar(10) NOT NULL,
+//	"bet_amount" int8 NOT NULL,
+//	"win_amount" int8 NOT NULL,
+//	"result" jsonb NOT NULL,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_game_spins_user_key" ON "public"."game_spins" ("user_id", "idempotency_key");
+type GameSpin struct {
+	ID             int64     `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt      time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID     int       `gorm:"column:operator_id;not null" json:"operator_id" example:"1"`
+	UserID         int       `gorm:"column:user_id;not null;uniqueIndex:idx_game_spins_user_key" json:"user_id" example:"1"`
+	RoundID        string    `gorm:"column:round_id;type:varchar(64);not null" json:"round_id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	RevisionHash   string    `gorm:"column:revision_hash;type:varchar(64);not null" json:"revision_hash" example:"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"`
+	IdempotencyKey *string   `gorm:"column:idempotency_key;type:varchar(64);uniqueIndex:idx_game_spins_user_key" json:"idempotency_key,omitempty" example:"b7c1e6a4-6a0e-4f0c-9a57-1f2d0c3e9b11"`
+	RequestHash    string    `gorm:"column:request_hash;type:varchar(64);not null" json:"-"`
+	Currency       string    `gorm:"column:currency;type:varchar(10);not null" json:"currency" example:"TWD"`
+	BetAmount      int64     `gorm:"column:bet_amount;not null" json:"bet_amount" example:"150"` // 以貨幣最小單位計
+	WinAmount      int64     `gorm:"column:win_amount;not null" json:"win_amount" example:"1050"`
+	Result         string    `gorm:"column:result;type:jsonb;not null" json:"-"`
+}
+
+// TableName 指定資料表名稱
+func (GameSpin) TableName() string {
+	return "game_spins"
+}
@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"time"
+)
+
+// Operator 營運商（租戶）資料表結構，營運商以 API 金鑰呼叫整合介面，資料庫只保存金鑰的雜湊
+// 直接在平台註冊的使用者屬於代碼為 platform 的營運商
+// 設定 wallet_url 的營運商使用外部錢包，扣款及派彩送往營運商的錢包網址並以其密鑰簽章；未設定時使用內部帳本
+// jurisdiction 為營運商所在的司法管轄區，與 autoplay_disabled、autoplay_max_spins 共同決定玩家可否使用自動旋轉
+// CREATE TABLE "public"."operators" (
+//
+//	"id" serial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"updated_at" timestamp NOT NULL DEFAULT now(),
+//	"code" varchar(50) NOT NULL,
+//	"name" varchar(100) NOT NULL,
+//	"api_key_hash" varchar(64) NOT NULL,
+//	"status" varchar(20) NOT NULL DEFAULT 'active',
+//	"wallet_url" varchar(255) NOT NULL DEFAULT '',
+//	"wallet_secret" varchar(255) NOT NULL DEFAULT '',
+//	"jurisdiction" varchar(10) NOT NULL DEFAULT '',
+//	"autoplay_disabled" bool NOT NULL DEFAULT false,
+//	"autoplay_max_spins" int4 NOT NULL DEFAULT 100,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_operators_code" ON "public"."operators" ("code");
+// CREATE UNIQUE INDEX "idx_operators_api_key_hash" ON "public"."operators" ("api_key_hash");
+type Operator struct {
+	ID           int       `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt    time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	Code         string    `gorm:"column:code;type:varchar(50);not null;uniqueIndex:idx_operators_code" json:"code" example:"acme"`
+	Name         string    `gorm:"column:name;type:varchar(100);not null" json:"name" example:"Acme Casino"`
+	APIKeyHash   string    `gorm:"column:api_key_hash;type:varchar(64);not null;uniqueIndex:idx_operators_api_key_hash" json:"-"`
+	Status       string    `gorm:"column:status;type:varchar(20);not null;default:active" json:"status" example:"active"`
+	WalletURL    string    `gorm:"column:wallet_url;type:varchar(255);not null;default:''" json:"wallet_url" example:"https://wallet.acme.example.com"`
+	WalletSecret string    `gorm:"column:wallet_secret;type:varchar(255);not null;default:''" json:"-"` // 錢包請求簽章使用的 HMAC 密鑰
+
+	Jurisdiction     string `gorm:"column:jurisdiction;type:varchar(10);not null;default:''" json:"jurisdiction" example:"MT"` // 司法管轄區代碼，例如 UK、MT
+	AutoplayDisabled bool   `gorm:"column:autoplay_disabled;not null;default:false" json:"autoplay_disabled" example:"false"`
+	AutoplayMaxSpins int    `gorm:"column:autoplay_max_spins;not null;default:100" json:"autoplay_max_spins" example:"100"` // 單次自動旋轉的次數上限，0 代表不限制
+}
+
+// PlatformOperatorCode 平台本身的營運商代碼
+const PlatformOperatorCode = "platform"
+
+// 營運商狀態
+const (
+	OperatorStatusActive   = "active"
+	OperatorStatusDisabled = "disabled"
+)
+
+// TableName 指定資料表名稱
+func (Operator) TableName() string {
+	return "operators"
+}
+
+// OperatorPlayer 營運商玩家與本服務使用者的對應
+// CREATE TABLE "public"."operator_players" (
+//
+//	"id" serial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"external_id" varchar(100) NOT NULL,
+//	"user_id" int4 NOT NULL REFERENCES "users" ("id"),
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_operator_players_external" ON "public"."operator_players" ("operator_id", "external_id");
+// CREATE UNIQUE INDEX "idx_operator_players_user" ON "public"."operator_players" ("user_id");
+type OperatorPlayer struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID int       `gorm:"column:operator_id;not null;uniqueIndex:idx_operator_players_external" json:"operator_id" example:"1"`
+	ExternalID string    `gorm:"column:external_id;type:varchar(100);not null;uniqueIndex:idx_operator_players_external" json:"external_id" example:"player-8842"`
+	UserID     int       `gorm:"column:user_id;not null;uniqueIndex:idx_operator_players_user" json:"user_id" example:"1"`
+}
+
+// TableName 指定資料表名稱
+func (OperatorPlayer) TableName() string {
+	return "operator_players"
+}
+
+// OperatorGame 營運商對遊戲的個別設定，沒有設定時遊戲預設開放
+// CREATE TABLE "public"."operator_games" (
+//
+//	"id" serial NOT NULL,
+//	"updated_at" timestamp NOT NULL DEFAULT now(),
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"game_id" varchar(50) NOT NULL,
+//	"enabled" bool NOT NULL DEFAULT true,
+//	"branding" jsonb NOT NULL DEFAULT '{}',
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_operator_games_operator_game" ON "public"."operator_games" ("operator_id", "game_id");
+type OperatorGame struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID int       `gorm:"column:operator_id;not null;uniqueIndex:idx_operator_games_operator_game" json:"operator_id" example:"1"`
+	GameID     string    `gorm:"column:game_id;type:varchar(50);not null;uniqueIndex:idx_operator_games_operator_game" json:"game_id" example:"classic"`
+	Enabled    bool      `gorm:"column:enabled;not null;default:true" json:"enabled" example:"true"`
+	Branding   string    `gorm:"column:branding;type:jsonb;not null;default:'{}'" json:"-"` // 前端使用的品牌設定，例如標誌及配色
+}
+
+// TableName 指定資料表名稱
+func (OperatorGame) TableName() string {
+	return "operator_games"
+}
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// 使用者角色
+const (
+	RolePlayer  = "player"
+	RoleSupport = "support"
+	RoleFinance = "finance"
+	RoleAdmin   = "admin"
+)
+
+// 權限，以 JWT claims 傳遞並由 middleware.RequirePermission 檢查
+const (
+	PermUsersRead       = "users:read"
+	PermUsersWrite      = "users:write"
+	PermUsersBlock      = "users:block"
+	PermUsersRoles      = "users:roles"
+	PermRoundsRead      = "rounds:read"
+	PermRoundsClose     = "rounds:close"
+	PermWalletsAdjust   = "wallets:adjust"
+	PermReportsRead     = "reports:read"
+	PermRatesWrite      = "rates:write"
+	PermGamesConfig     = "games:config"
+	PermOperatorsManage = "operators:manage"
+)
+
+// AllPermissions 所有權限
+var AllPermissions = []string{
+	PermUsersRead,
+	PermUsersWrite,
+	PermUsersBlock,
+	PermUsersRoles,
+	PermRoundsRead,
+	PermRoundsClose,
+	PermWalletsAdjust,
+	PermReportsRead,
+	PermRatesWrite,
+	PermGamesConfig,
+	PermOperatorsManage,
+}
+
+// RolePermissions 各角色預設擁有的權限，玩家只能進行遊戲
+var RolePermissions = map[string][]string{
+	RolePlayer:  {},
+	RoleSupport: {PermUsersRead, PermUsersBlock, PermRoundsRead, PermRoundsClose},
+	RoleFinance: {PermUsersRead, PermWalletsAdjust, PermReportsRead, PermRatesWrite},
+	RoleAdmin:   AllPermissions,
+}
+
+// ValidRole 檢查角色是否存在
+func ValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// ValidPermission 檢查權限是否存在
+func ValidPermission(permission string) bool {
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtraPermissions 返回角色以外額外授予的權限
+func (u *User) ExtraPermissions() []string {
+	var permissions []string
+	if u.Permissions == "" {
+		return permissions
+	}
+	if err := json.Unmarshal([]byte(u.Permissions), &permissions); err != nil {
+		return nil
+	}
+	return permissions
+}
+
+// RequiresTwoFactor 擁有任何管理權限的使用者必須啟用兩步驟驗證
+func (u *User) RequiresTwoFactor() bool {
+	return len(u.EffectivePermissions()) > 0
+}
+
+// EffectivePermissions 返回角色權限與額外權限的聯集，依名稱排序
+func (u *User) EffectivePermissions() []string {
+	set := make(map[string]struct{})
+	for _, p := range RolePermissions[u.Role] {
+		set[p] = struct{}{}
+	}
+	for _, p := range u.ExtraPermissions() {
+		set[p] = struct{}{}
+	}
+
+	permissions := make([]string, 0, len(set))
+	for p := range set {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+)
+
+// 電話驗證的用途，不同用途的驗證碼不可互用
+const (
+	VerificationPurposeRegister      = "register"
+	VerificationPurposeResetPassword = "reset_password"
+)
+
+// PhoneVerification 發送到電話的一次性驗證碼，驗證碼及驗證通過後的 token 都只保存雜湊
+// 同一電話及用途只有最新一筆驗證碼有效
+// CREATE TABLE "public"."phone_verifications" (
+//
+//	"id" bigserial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"phone" varchar(20) NOT NULL,
+//	"purpose" varchar(20) NOT NULL,
+//	"code_hash" varchar(60) NOT NULL,
+//	"attempts" int4 NOT NULL DEFAULT 0,
+//	"expires_at" timestamp NOT NULL,
+//	"verified_at" timestamp,
+//	"token_hash" varchar(64),
+//	"token_expires_at" timestamp,
+//	"consumed_at" timestamp,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE INDEX "idx_phone_verifications_phone" ON "public"."phone_verifications" ("operator_id", "phone", "purpose", "created_at");
+// CREATE UNIQUE INDEX "idx_phone_verifications_token_hash" ON "public"."phone_verifications" ("token_hash");
+type PhoneVerification struct {
+	ID             int64      `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt      time.Time  `gorm:"column:created_at;not null;default:now();index:idx_phone_verifications_phone,priority:4" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID     int        `gorm:"column:operator_id;not null;index:idx_phone_verifications_phone,priority:1" json:"operator_id" example:"1"`
+	Phone          string     `gorm:"column:phone;type:varchar(20);not null;index:idx_phone_verifications_phone,priority:2" json:"phone" example:"0987654321"`
+	Purpose        string     `gorm:"column:purpose;type:varchar(20);not null;index:idx_phone_verifications_phone,priority:3" json:"purpose" example:"register"`
+	CodeHash       string     `gorm:"column:code_hash;type:varchar(60);not null" json:"-"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0" json:"attempts" example:"0"`
+	ExpiresAt      time.Time  `gorm:"column:expires_at;not null" json:"expires_at" example:"2025-02-16T16:10:00.763995Z"`
+	VerifiedAt     *time.Time `gorm:"column:verified_at" json:"verified_at,omitempty" example:"2025-02-16T16:06:00.763995Z"`
+	TokenHash      *string    `gorm:"column:token_hash;type:varchar(64);uniqueIndex:idx_phone_verifications_token_hash" json:"-"`
+	TokenExpiresAt *time.Time `gorm:"column:token_expires_at" json:"token_expires_at,omitempty" example:"2025-02-16T16:21:00.763995Z"`
+	ConsumedAt     *time.Time `gorm:"column:consumed_at" json:"consumed_at,omitempty" example:"2025-02-16T16:07:00.763995Z"`
+}
+
+// TableName 指定資料表名稱
+func (PhoneVerification) TableName() string {
+	return "phone_verifications"
+}
@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"time"
+)
+
+// RefreshToken 伺服器端保存的 refresh token，只保存雜湊
+// 每次使用後即撤銷並在同一個 family 中發出新的 token；已撤銷的 token 再被使用時整個 family 一併撤銷
+// CREATE TABLE "public"."refresh_tokens" (
+//
+//	"id" bigserial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"user_id" int4 NOT NULL REFERENCES "users" ("id"),
+//	"family_id" varchar(32) NOT NULL,
+//	"token_hash" varchar(64) NOT NULL,
+//	"expires_at" timestamp NOT NULL,
+//	"revoked_at" timestamp,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_refresh_tokens_token_hash" ON "public"."refresh_tokens" ("token_hash");
+// CREATE INDEX "idx_refresh_tokens_user" ON "public"."refresh_tokens" ("user_id");
+// CREATE INDEX "idx_refresh_tokens_family" ON "public"."refresh_tokens" ("family_id");
+type RefreshToken struct {
+	ID        int64      `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt time.Time  `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	UserID    int        `gorm:"column:user_id;not null;index:idx_refresh_tokens_user" json:"user_id" example:"1"`
+	FamilyID  string     `gorm:"column:family_id;type:varchar(32);not null;index:idx_refresh_tokens_family" json:"family_id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex:idx_refresh_tokens_token_hash" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expires_at" example:"2025-03-18T16:05:00.763995Z"`
+	RevokedAt *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty" example:"2025-02-16T16:05:00.763995Z"`
+}
+
+// TableName 指定資料表名稱
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RevokedToken 在到期前被撤銷的 access token，過期後即可刪除
+// CREATE TABLE "public"."revoked_tokens" (
+//
+//	"jti" varchar(32) NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"expires_at" timestamp NOT NULL,
+//	PRIMARY KEY ("jti")
+//
+// );
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;column:jti;type:varchar(32)" json:"jti" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	CreatedAt time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null" json:"expires_at" example:"2025-02-16T16:20:00.763995Z"`
+}
+
+// TableName 指定資料表名稱
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
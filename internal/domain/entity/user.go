@@ -2,36 +2,96 @@ package entity
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// User 資料表結構
+// User 資料表結構，每位使用者屬於一個營運商
+// 刪除為軟刪除，已刪除的使用者不會出現在查詢中，電話可以重新註冊；營運商玩家沒有電話
 // CREATE TABLE "public"."users" (
 //
 //	"id" int4 NOT NULL DEFAULT nextval('users_id_seq'::regclass),
 //	"created_at" timestamp NOT NULL DEFAULT now(),
 //	"updated_at" timestamp NOT NULL DEFAULT now(),
 //	"deleted_at" timestamp,
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
 //	"name" varchar(20) NOT NULL,
 //	"phone" varchar(20) NOT NULL,
 //	"password" varchar(200) NOT NULL,
+//	"role" varchar(20) NOT NULL DEFAULT 'player',
+//	"permissions" jsonb NOT NULL DEFAULT '[]',
+//	"status" varchar(20) NOT NULL DEFAULT 'active',
+//	"blocked_at" timestamp,
+//	"blocked_reason" varchar(255),
+//	"totp_secret" varchar(64),
+//	"totp_enabled_at" timestamp,
+//	"totp_last_step" int8 NOT NULL DEFAULT 0,
 //	PRIMARY KEY ("id")
 //
 // );
+// CREATE INDEX "idx_users_operator" ON "public"."users" ("operator_id");
+// CREATE INDEX "idx_users_deleted_at" ON "public"."users" ("deleted_at");
+// CREATE UNIQUE INDEX "idx_users_operator_phone" ON "public"."users" ("operator_id", "phone") WHERE length("phone") > 0 AND "deleted_at" IS NULL;
 type User struct {
-	ID        int        `gorm:"primaryKey;column:id" json:"id" example:"1"`
-	CreatedAt time.Time  `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
-	UpdatedAt time.Time  `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
-	DeletedAt *time.Time `gorm:"column:deleted_at" json:"deleted_at,omitempty" example:"2025-02-16T16:05:00.763995Z"`
-	Name      string     `gorm:"column:name;type:varchar(20);not null" json:"name" binding:"required,max=20" example:"testdemo001"`
-	Phone     string     `gorm:"column:phone;type:varchar(20);not null" json:"phone" binding:"required,max=20" example:"0987654321"`
-	Password  string     `gorm:"column:password;type:varchar(200);not null" json:"-"`
+	ID            int            `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt     time.Time      `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	UpdatedAt     time.Time      `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	DeletedAt     gorm.DeletedAt `gorm:"column:deleted_at;index:idx_users_deleted_at" json:"deleted_at" swaggertype:"string" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID    int            `gorm:"column:operator_id;not null;index:idx_users_operator;uniqueIndex:idx_users_operator_phone,where:length(phone) > 0 AND deleted_at IS NULL" json:"operator_id" example:"1"`
+	Name          string         `gorm:"column:name;type:varchar(20);not null" json:"name" binding:"required,max=20" example:"testdemo001"`
+	Phone         string         `gorm:"column:phone;type:varchar(20);not null;uniqueIndex:idx_users_operator_phone" json:"phone" binding:"required,max=20" example:"0987654321"`
+	Password      string         `gorm:"column:password;type:varchar(200);not null" json:"-"`
+	Role          string         `gorm:"column:role;type:varchar(20);not null;default:player" json:"role" example:"player"`
+	Permissions   string         `gorm:"column:permissions;type:jsonb;not null;default:'[]'" json:"-"` // 角色以外額外授予的權限
+	Status        string         `gorm:"column:status;type:varchar(20);not null;default:active" json:"status" example:"active"`
+	BlockedAt     *time.Time     `gorm:"column:blocked_at" json:"blocked_at,omitempty" example:"2025-02-16T16:05:00.763995Z"`
+	BlockedReason *string        `gorm:"column:blocked_reason;type:varchar(255)" json:"blocked_reason,omitempty" example:"suspected bonus abuse"`
+	TOTPSecret    *string        `gorm:"column:totp_secret;type:varchar(64)" json:"-"` // 兩步驟驗證金鑰，確認前尚未啟用
+	TOTPEnabledAt *time.Time     `gorm:"column:totp_enabled_at" json:"totp_enabled_at,omitempty" example:"2025-02-16T16:05:00.763995Z"`
+	TOTPLastStep  int64          `gorm:"column:totp_last_step;not null;default:0" json:"-"` // 最後使用的驗證碼時間區段，同一驗證碼不可重複使用
 }
 
+// 使用者狀態，停用的使用者無法登入及下注
+const (
+	UserStatusActive  = "active"
+	UserStatusBlocked = "blocked"
+)
+
 // TableName 指定資料表名稱
 func (User) TableName() string {
 	return "users"
 }
 
+// TwoFactorEnabled 是否已啟用兩步驟驗證
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// RecoveryCode 無法使用驗證器時代替驗證碼的備用碼，只保存雜湊，每組只能使用一次
+// CREATE TABLE "public"."recovery_codes" (
+//
+//	"id" bigserial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"user_id" int4 NOT NULL REFERENCES "users" ("id"),
+//	"code_hash" varchar(64) NOT NULL,
+//	"used_at" timestamp,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_recovery_codes_user_code" ON "public"."recovery_codes" ("user_id", "code_hash");
+type RecoveryCode struct {
+	ID        int64      `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt time.Time  `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	UserID    int        `gorm:"column:user_id;not null;uniqueIndex:idx_recovery_codes_user_code,priority:1" json:"user_id" example:"1"`
+	CodeHash  string     `gorm:"column:code_hash;type:varchar(64);not null;uniqueIndex:idx_recovery_codes_user_code,priority:2" json:"-"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at,omitempty" example:"2025-02-16T16:05:00.763995Z"`
+}
+
+// TableName 指定資料表名稱
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
 // BeforeCreate 在創建記錄前自動設置時間戳
 func (u *User) BeforeCreate() error {
 	now := time.Now()
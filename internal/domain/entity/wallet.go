@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"time"
+)
+
+// Wallet 使用者錢包資料表結構，每位使用者每種貨幣一個餘額
+// CREATE TABLE "public"."wallets" (
+//
+//	"id" serial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"updated_at" timestamp NOT NULL DEFAULT now(),
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"user_id" int4 NOT NULL,
+//	"currency" varchar(10) NOT NULL,
+//	"balance" int8 NOT NULL DEFAULT 0,
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_wallets_user_currency" ON "public"."wallets" ("user_id", "currency");
+type Wallet struct {
+	ID         int       `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;not null;default:now()" json:"updated_at" example:"2025-02-16T16:05:00.763995Z"`
+	OperatorID int       `gorm:"column:operator_id;not null" json:"operator_id" example:"1"`
+	UserID     int       `gorm:"column:user_id;not null;uniqueIndex:idx_wallets_user_currency" json:"user_id" example:"1"`
+	Currency   string    `gorm:"column:currency;type:varchar(10);not null;uniqueIndex:idx_wallets_user_currency" json:"currency" example:"TWD"`
+	Balance    int64     `gorm:"column:balance;not null;default:0" json:"balance" example:"10000"` // 以貨幣最小單位計
+}
+
+// TableName 指定資料表名稱
+func (Wallet) TableName() string {
+	return "wallets"
+}
+
+// 錢包交易類型
+const (
+	WalletTxBet      = "bet"
+	WalletTxWin      = "win"
+	WalletTxRollback = "rollback"
+	WalletTxDeposit  = "deposit"
+	WalletTxAdjust   = "adjustment"
+)
+
+// WalletTransaction 錢包交易明細資料表結構，只新增不修改
+// CREATE TABLE "public"."wallet_transactions" (
+//
+//	"id" bigserial NOT NULL,
+//	"created_at" timestamp NOT NULL DEFAULT now(),
+//	"transaction_id" varchar(100) NOT NULL,
+//	"wallet_id" int4 NOT NULL REFERENCES "wallets" ("id"),
+//	"operator_id" int4 NOT NULL REFERENCES "operators" ("id"),
+//	"user_id" int4 NOT NULL,
+//	"currency" varchar(10) NOT NULL,
+//	"type" varchar(20) NOT NULL,
+//	"amount" int8 NOT NULL,
+//	"balance_after" int8 NOT NULL,
+//	"round_id" varchar(64),
+//	"reason" varchar(255),
+//	"actor_id" int4 REFERENCES "users" ("id"),
+//	PRIMARY KEY ("id")
+//
+// );
+// CREATE UNIQUE INDEX "idx_wallet_transactions_transaction_id" ON "public"."wallet_transactions" ("transaction_id");
+// CREATE INDEX "idx_wallet_transactions_wallet" ON "public"."wallet_transactions" ("wallet_id");
+type WalletTransaction struct {
+	ID            int64     `gorm:"primaryKey;column:id" json:"id" example:"1"`
+	CreatedAt     time.Time `gorm:"column:created_at;not null;default:now()" json:"created_at" example:"2025-02-16T16:05:00.763995Z"`
+	TransactionID string    `gorm:"column:transaction_id;type:varchar(100);not null;uniqueIndex:idx_wallet_transactions_transaction_id" json:"transaction_id" example:"9f86d081884c7d659a2feaa0c55ad015-bet"` // 冪等鍵，重複的交易只處理一次
+	WalletID      int       `gorm:"column:wallet_id;not null;index:idx_wallet_transactions_wallet" json:"wallet_id" example:"1"`
+	OperatorID    int       `gorm:"column:operator_id;not null" json:"operator_id" example:"1"`
+	UserID        int       `gorm:"column:user_id;not null" json:"user_id" example:"1"`
+	Currency      string    `gorm:"column:currency;type:varchar(10);not null" json:"currency" example:"TWD"`
+	Type          string    `gorm:"column:type;type:varchar(20);not null" json:"type" example:"bet"`
+	Amount        int64     `gorm:"column:amount;not null" json:"amount" example:"-100"` // 帶正負號，扣款為負數
+	BalanceAfter  int64     `gorm:"column:balance_after;not null" json:"balance_after" example:"9900"`
+	RoundID       *string   `gorm:"column:round_id;type:varchar(64)" json:"round_id,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	Reason        *string   `gorm:"column:reason;type:varchar(255)" json:"reason,omitempty" example:"welcome bonus"`
+	ActorID       *int      `gorm:"column:actor_id" json:"actor_id,omitempty" example:"2"` // 人工入帳或調整的管理者
+}
+
+// TableName 指定資料表名稱
+func (WalletTransaction) TableName() string {
+	return "wallet_transactions"
+}
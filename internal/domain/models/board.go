@@ -3,15 +3,17 @@ package models
 import (
 	"fmt"
 	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/pkg/money"
 )
 
 type Board [3][3]domain.Symbol
 
 type WinningLine struct {
-	Type     string
-	Position int
-	Symbol   domain.Symbol
-	Payout   float64
+	Type       string        `json:"type"`
+	Position   int           `json:"position"`
+	Symbol     domain.Symbol `json:"symbol"`
+	Multiplier float64       `json:"multiplier"` // 獎金倍數（以下注金額計）
+	Payout     money.Money   `json:"payout"`
 }
 
 func (b Board) PrintBoard() string {
@@ -0,0 +1,59 @@
+package models
+
+import "passontw-slot-game/internal/domain"
+
+// Overlay 代表同一局中持續覆蓋在盤面上的符號，例如黏性百搭
+type Overlay struct {
+	Symbols [3][3]domain.Symbol `json:"symbols"`
+	Locked  [3][3]bool          `json:"locked"`
+}
+
+// Set 鎖定指定位置的符號
+func (o *Overlay) Set(row, col int, symbol domain.Symbol) {
+	o.Symbols[row][col] = symbol
+	o.Locked[row][col] = true
+}
+
+// Clear 解除指定位置的鎖定
+func (o *Overlay) Clear(row, col int) {
+	o.Symbols[row][col] = 0
+	o.Locked[row][col] = false
+}
+
+// Count 返回已鎖定的位置數量
+func (o Overlay) Count() int {
+	count := 0
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if o.Locked[i][j] {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Apply 將覆蓋層合併到生成的盤面上
+func (o Overlay) Apply(board Board) Board {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if o.Locked[i][j] {
+				board[i][j] = o.Symbols[i][j]
+			}
+		}
+	}
+	return board
+}
+
+// ShiftLeft 將所有鎖定的符號往左移動一軸，移出盤面的符號會被移除
+func (o *Overlay) ShiftLeft() {
+	var shifted Overlay
+	for i := 0; i < 3; i++ {
+		for j := 1; j < 3; j++ {
+			if o.Locked[i][j] {
+				shifted.Set(i, j-1, o.Symbols[i][j])
+			}
+		}
+	}
+	*o = shifted
+}
@@ -0,0 +1,39 @@
+package domain
+
+// reelOffset 每條輪帶的起始偏移，讓三條輪帶的排列不同
+const reelOffset = 7
+
+// GetReelStrips 返回經典水果機模式使用的三條輪帶
+func GetReelStrips() [3][]Symbol {
+	return BuildReelStrips(GetSymbolList())
+}
+
+// BuildReelStrips 根據符號配置建立三條輪帶
+// 輪帶依照符號權重交錯排列，金幣不出現在輪帶上
+func BuildReelStrips(symbols []SymbolInfo) [3][]Symbol {
+	remaining := make(map[Symbol]int)
+	for _, info := range symbols {
+		if info.Symbol != Coin {
+			remaining[info.Symbol] = info.Weight
+		}
+	}
+
+	var strip []Symbol
+	for added := true; added; {
+		added = false
+		for _, info := range symbols {
+			if remaining[info.Symbol] > 0 {
+				strip = append(strip, info.Symbol)
+				remaining[info.Symbol]--
+				added = true
+			}
+		}
+	}
+
+	var strips [3][]Symbol
+	for reel := range strips {
+		offset := (reel * reelOffset) % len(strip)
+		strips[reel] = append(append([]Symbol{}, strip[offset:]...), strip[:offset]...)
+	}
+	return strips
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "fmt"
+
 // Symbol 代表老虎機的符號
 type Symbol int
 
@@ -15,13 +17,15 @@ const (
 	Diamond
 	Seven
 	BAR
+	Wild
+	Coin
 )
 
 // SymbolInfo 儲存符號的相關資訊
 type SymbolInfo struct {
-	Symbol Symbol
-	Weight int     // 出現權重
-	Payout float64 // 獎金倍數
+	Symbol Symbol  `json:"symbol"`
+	Weight int     `json:"weight"` // 出現權重
+	Payout float64 `json:"payout"` // 獎金倍數
 }
 
 // String 方法用於將 Symbol 轉換為字串表示
@@ -37,10 +41,37 @@ func (s Symbol) String() string {
 		"💎",   // Diamond
 		"7️⃣", // Seven
 		"📊",   // BAR
+		"🃏",   // Wild
+		"🪙",   // Coin
+	}
+	if s < 0 || int(s) >= len(symbols) {
+		return fmt.Sprintf("Symbol(%d)", int(s))
 	}
 	return symbols[s]
 }
 
+// Code 返回符號的代碼名稱，用於以名稱編碼盤面
+func (s Symbol) Code() string {
+	codes := []string{
+		"cherry",
+		"bell",
+		"lemon",
+		"orange",
+		"star",
+		"skull",
+		"crown",
+		"diamond",
+		"seven",
+		"bar",
+		"wild",
+		"coin",
+	}
+	if s < 0 || int(s) >= len(codes) {
+		return fmt.Sprintf("unknown_%d", int(s))
+	}
+	return codes[s]
+}
+
 // GetSymbolList 返回所有符號的配置信息
 func GetSymbolList() []SymbolInfo {
 	return []SymbolInfo{
@@ -54,5 +85,7 @@ func GetSymbolList() []SymbolInfo {
 		{Diamond, 2, 10.0}, // 較稀有，獎金較高
 		{Seven, 3, 7.0},
 		{BAR, 5, 4.0},
+		{Wild, 2, 10.0}, // 百搭，可替代任何符號
+		{Coin, 6, 0},    // 金幣，不參與連線，用於觸發 Hold and Spin
 	}
 }
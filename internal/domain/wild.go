@@ -0,0 +1,22 @@
+package domain
+
+import "fmt"
+
+// WildModifier 代表百搭符號的特殊效果
+type WildModifier string
+
+const (
+	StickyWild    WildModifier = "sticky"    // 黏性百搭：在同一局的後續免費旋轉中保留在盤面上
+	ExpandingWild WildModifier = "expanding" // 擴展百搭：擴展填滿所在的整軸
+	WalkingWild   WildModifier = "walking"   // 移動百搭：每次旋轉往左移動一軸
+)
+
+// ParseWildModifier 將字串轉換為百搭效果
+func ParseWildModifier(value string) (WildModifier, error) {
+	switch modifier := WildModifier(value); modifier {
+	case StickyWild, ExpandingWild, WalkingWild:
+		return modifier, nil
+	default:
+		return "", fmt.Errorf("unknown wild modifier: %s", value)
+	}
+}
@@ -0,0 +1,511 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/service"
+	"passontw-slot-game/pkg/money"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recentSpinLimit 玩家資料中顯示的最近旋轉筆數
+const recentSpinLimit = 20
+
+type RecentSpinInfo struct {
+	RoundID   string    `json:"roundId" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	Currency  string    `json:"currency" example:"TWD"`
+	BetAmount string    `json:"betAmount" example:"1.50"`
+	WinAmount string    `json:"winAmount" example:"10.50"`
+	CreatedAt time.Time `json:"createdAt" example:"2025-02-16T16:05:00.763995Z"`
+}
+
+type PlayerResponse struct {
+	Success     bool             `json:"success" example:"true"`
+	User        entity.User      `json:"user"`
+	Wallets     []BalanceInfo    `json:"wallets"`
+	RecentSpins []RecentSpinInfo `json:"recentSpins"`
+}
+
+type AdjustBalanceRequest struct {
+	Currency string `json:"currency" binding:"required,alpha,max=10" example:"TWD"`
+	Amount   string `json:"amount" binding:"required" example:"-50.00"`
+	Reason   string `json:"reason" binding:"required,max=255" example:"chargeback"`
+}
+
+type RoleRequest struct {
+	Role        string   `json:"role" binding:"required" example:"support"`
+	Permissions []string `json:"permissions" example:"reports:read"`
+}
+
+type RoleResponse struct {
+	Success     bool     `json:"success" example:"true"`
+	UserID      int      `json:"userId" example:"1"`
+	Role        string   `json:"role" example:"support"`
+	Permissions []string `json:"permissions" example:"rounds:read,users:read"`
+}
+
+type BlockRequest struct {
+	Reason string `json:"reason" binding:"required,max=255" example:"suspected bonus abuse"`
+}
+
+type ClosedRoundInfo struct {
+	RoundID   string `json:"roundId" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	Currency  string `json:"currency" example:"TWD"`
+	BetAmount string `json:"betAmount" example:"1.50"`
+	TotalWin  string `json:"totalWin" example:"10.50"`
+}
+
+type CloseRoundsResponse struct {
+	Success bool              `json:"success" example:"true"`
+	Rounds  []ClosedRoundInfo `json:"rounds"`
+}
+
+// AdminHandler 後台的玩家管理，只能操作管理者所屬營運商的玩家，平台管理者可指定營運商
+// 異動玩家資料或餘額的操作都會寫入稽核紀錄
+type AdminHandler struct {
+	userService   service.UserService
+	walletService service.WalletService
+	gameService   service.GameService
+	auditService  service.AuditService
+}
+
+func NewAdminHandler(userService service.UserService, walletService service.WalletService, gameService service.GameService, auditService service.AuditService) *AdminHandler {
+	return &AdminHandler{
+		userService:   userService,
+		walletService: walletService,
+		gameService:   gameService,
+		auditService:  auditService,
+	}
+}
+
+// SearchPlayers godoc
+// @Summary      Search players
+// @Description  Search the players of the caller's operator by name, phone or ID; platform admins target another operator through /admin/operators/{id}/users
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        q         query  string  false  "Name, phone or user ID"
+// @Param        page      query  int     false  "Page number (default: 1)"
+// @Param        page_size query  int     false  "Page size (default: 10)"
+// @Success      200  {object}  PaginatedResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/users [get]
+// @Router       /api/v1/admin/operators/{id}/users [get]
+func (h *AdminHandler) SearchPlayers(c *gin.Context) {
+	operatorID, ok := getTargetOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid operator",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	users, total, err := h.userService.SearchUsers(operatorID, c.Query("q"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to search users",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       users,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (int(total) + pageSize - 1) / pageSize,
+	})
+}
+
+// GetPlayer godoc
+// @Summary      Get player
+// @Description  Get a player's profile, wallet balances and most recent spins
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "User ID"
+// @Success      200  {object}  PlayerResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id} [get]
+// @Router       /api/v1/admin/operators/{id}/users/{userId} [get]
+func (h *AdminHandler) GetPlayer(c *gin.Context) {
+	operatorID, userID, ok := h.playerParams(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(operatorID, userID)
+	if err != nil {
+		h.playerError(c, err)
+		return
+	}
+
+	balances, err := h.walletService.Balances(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get wallets",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+	spins, err := h.gameService.RecentSpins(operatorID, userID, recentSpinLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get recent spins",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	response := PlayerResponse{
+		Success:     true,
+		User:        *user,
+		Wallets:     make([]BalanceInfo, 0, len(balances)),
+		RecentSpins: make([]RecentSpinInfo, 0, len(spins)),
+	}
+	for _, balance := range balances {
+		response.Wallets = append(response.Wallets, newBalanceInfo(balance))
+	}
+	for _, spin := range spins {
+		currency := money.Currency(spin.Currency)
+		response.RecentSpins = append(response.RecentSpins, RecentSpinInfo{
+			RoundID:   spin.RoundID,
+			Currency:  spin.Currency,
+			BetAmount: money.New(spin.BetAmount, currency).String(),
+			WinAmount: money.New(spin.WinAmount, currency).String(),
+			CreatedAt: spin.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AdjustBalance godoc
+// @Summary      Adjust player balance
+// @Description  Credit or debit a player's wallet; a negative amount debits, and the mandatory reason and the acting admin are recorded in the wallet ledger and audit log
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path  int                   true  "User ID"
+// @Param        request body  AdjustBalanceRequest  true  "Adjustment"
+// @Success      200  {object}  BalanceInfo
+// @Failure      400  {object}  ErrorResponse
+// @Failure      402  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id}/wallets/adjust [post]
+// @Router       /api/v1/admin/operators/{id}/users/{userId}/wallets/adjust [post]
+func (h *AdminHandler) AdjustBalance(c *gin.Context) {
+	operatorID, userID, ok := h.playerParams(c)
+	if !ok {
+		return
+	}
+
+	var req AdjustBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	amount, err := money.Parse(req.Amount, requestCurrency(req.Currency, ""))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid amount",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	actorID, _ := getUserID(c)
+	balance, err := h.walletService.Adjust(operatorID, userID, actorID, amount, req.Reason)
+	if err != nil {
+		h.playerError(c, err)
+		return
+	}
+	recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditWalletAdjust, map[string]interface{}{
+		"currency": string(amount.Currency()),
+		"amount":   amount.String(),
+		"balance":  balance.String(),
+		"reason":   req.Reason,
+	})
+
+	c.JSON(http.StatusOK, newBalanceInfo(balance))
+}
+
+// BlockPlayer godoc
+// @Summary      Block player
+// @Description  Block a player from logging in and starting new rounds
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path  int           true  "User ID"
+// @Param        request body  BlockRequest  true  "Block reason"
+// @Success      200  {object}  entity.User
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id}/block [post]
+// @Router       /api/v1/admin/operators/{id}/users/{userId}/block [post]
+func (h *AdminHandler) BlockPlayer(c *gin.Context) {
+	operatorID, userID, ok := h.playerParams(c)
+	if !ok {
+		return
+	}
+
+	var req BlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, err := h.userService.Block(operatorID, userID, req.Reason)
+	if err != nil {
+		h.playerError(c, err)
+		return
+	}
+	recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditUserBlock, map[string]interface{}{
+		"reason": req.Reason,
+	})
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UnblockPlayer godoc
+// @Summary      Unblock player
+// @Description  Allow a blocked player to log in and play again
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "User ID"
+// @Success      200  {object}  entity.User
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id}/unblock [post]
+// @Router       /api/v1/admin/operators/{id}/users/{userId}/unblock [post]
+func (h *AdminHandler) UnblockPlayer(c *gin.Context) {
+	operatorID, userID, ok := h.playerParams(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.Unblock(operatorID, userID)
+	if err != nil {
+		h.playerError(c, err)
+		return
+	}
+	recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditUserUnblock, nil)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// RestorePlayer godoc
+// @Summary      Restore deleted player
+// @Description  Restore a soft-deleted user of the caller's operator. Fails with 409 when another user has registered the same phone since
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "User ID"
+// @Success      200  {object}  entity.User
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id}/restore [post]
+// @Router       /api/v1/admin/operators/{id}/users/{userId}/restore [post]
+func (h *AdminHandler) RestorePlayer(c *gin.Context) {
+	operatorID, userID, ok := h.playerParams(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.RestoreUser(operatorID, userID)
+	if err != nil {
+		h.playerError(c, err)
+		return
+	}
+	recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditUserRestore, nil)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// CloseRounds godoc
+// @Summary      Force-close open rounds
+// @Description  Settle every open round of a player; remaining free spins or respins are played out and paid
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "User ID"
+// @Success      200  {object}  CloseRoundsResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id}/rounds/close [post]
+// @Router       /api/v1/admin/operators/{id}/users/{userId}/rounds/close [post]
+func (h *AdminHandler) CloseRounds(c *gin.Context) {
+	operatorID, userID, ok := h.playerParams(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.userService.GetUser(operatorID, userID); err != nil {
+		h.playerError(c, err)
+		return
+	}
+
+	rounds, err := h.gameService.CloseOpenRounds(operatorID, userID)
+	if err != nil {
+		h.playerError(c, err)
+		return
+	}
+
+	response := CloseRoundsResponse{
+		Success: true,
+		Rounds:  make([]ClosedRoundInfo, 0, len(rounds)),
+	}
+	roundIDs := make([]string, 0, len(rounds))
+	for _, round := range rounds {
+		response.Rounds = append(response.Rounds, ClosedRoundInfo{
+			RoundID:   round.ID,
+			Currency:  string(round.BetAmount.Currency()),
+			BetAmount: round.BetAmount.String(),
+			TotalWin:  round.TotalWin.String(),
+		})
+		roundIDs = append(roundIDs, round.ID)
+	}
+	recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditRoundsClose, map[string]interface{}{
+		"rounds": roundIDs,
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+// SetRole godoc
+// @Summary      Set user role
+// @Description  Set a user's role and any permissions granted on top of it; takes effect on the user's next login
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path  int          true  "User ID"
+// @Param        request body  RoleRequest  true  "Role"
+// @Success      200  {object}  RoleResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id}/role [put]
+// @Router       /api/v1/admin/operators/{id}/users/{userId}/role [put]
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	operatorID, userID, ok := h.playerParams(c)
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, err := h.userService.SetRole(operatorID, userID, req.Role, req.Permissions)
+	if err != nil {
+		h.playerError(c, err)
+		return
+	}
+	recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditUserRole, map[string]interface{}{
+		"role":        user.Role,
+		"permissions": req.Permissions,
+	})
+
+	c.JSON(http.StatusOK, RoleResponse{
+		Success:     true,
+		UserID:      user.ID,
+		Role:        user.Role,
+		Permissions: user.EffectivePermissions(),
+	})
+}
+
+// recordAdminAction 以目前的管理者為執行者寫入管理操作的稽核紀錄，寫入失敗不影響操作結果
+func recordAdminAction(c *gin.Context, audit service.AuditService, operatorID, userID int, action string, detail map[string]interface{}) {
+	actorID, _ := getUserID(c)
+	err := audit.Record(service.AuditEntry{
+		OperatorID: operatorID,
+		UserID:     userID,
+		ActorID:    actorID,
+		Action:     action,
+		IP:         c.ClientIP(),
+		Detail:     detail,
+	})
+	if err != nil {
+		log.Printf("Failed to record %s of user %d: %v", action, userID, err)
+	}
+}
+
+// playerParams 取得操作的營運商及路徑中的使用者 ID
+func (h *AdminHandler) playerParams(c *gin.Context) (int, int, bool) {
+	operatorID, ok := getTargetOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid operator",
+			Code:  http.StatusUnauthorized,
+		})
+		return 0, 0, false
+	}
+
+	userID, err := strconv.Atoi(resourceParam(c, "userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid user id",
+			Code:  http.StatusBadRequest,
+		})
+		return 0, 0, false
+	}
+	return operatorID, userID, true
+}
+
+func (h *AdminHandler) playerError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrReasonRequired),
+		errors.Is(err, service.ErrInvalidRole):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrInsufficientFunds):
+		status = http.StatusPaymentRequired
+	case errors.Is(err, service.ErrOperatorManaged), errors.Is(err, service.ErrUserNotDeleted),
+		errors.Is(err, service.ErrPhoneRegistered):
+		status = http.StatusConflict
+	}
+	c.JSON(status, ErrorResponse{
+		Error: err.Error(),
+		Code:  status,
+	})
+}
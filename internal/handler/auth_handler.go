@@ -1,42 +1,121 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/token"
 	"passontw-slot-game/internal/service"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	userService service.UserService
+	userService     service.UserService
+	operatorService service.OperatorService
+	tokenService    service.TokenService
+	otpService      service.OTPService
+	loginGuard      service.LoginGuard
 }
 
-func NewAuthHandler(userService service.UserService) *AuthHandler {
+func NewAuthHandler(
+	userService service.UserService,
+	operatorService service.OperatorService,
+	tokenService service.TokenService,
+	otpService service.OTPService,
+	loginGuard service.LoginGuard,
+) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
+		userService:     userService,
+		operatorService: operatorService,
+		tokenService:    tokenService,
+		otpService:      otpService,
+		loginGuard:      loginGuard,
 	}
 }
 
+// errInvalidCredentials 電話不存在及密碼錯誤使用相同的回應
+const errInvalidCredentials = "invalid phone or password"
+
 type LoginRequest struct {
 	Phone    string `json:"phone" binding:"required" example:"0987654321"`
 	Password string `json:"password" binding:"required" example:"a12345678"`
 }
 
 type LoginResponse struct {
-	Token string      `json:"token"  example:"0987654321"`
-	User  interface{} `json:"user"`
+	Token            string      `json:"token"`
+	ExpiresAt        time.Time   `json:"expiresAt"`
+	RefreshToken     string      `json:"refreshToken"`
+	RefreshExpiresAt time.Time   `json:"refreshExpiresAt"`
+	User             interface{} `json:"user,omitempty"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired  bool      `json:"twoFactorRequired" example:"true"`
+	EnrollmentRequired bool      `json:"enrollmentRequired" example:"false"`
+	ChallengeToken     string    `json:"challengeToken"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type OTPRequest struct {
+	Phone string `json:"phone" binding:"required,min=1,max=20" example:"0987654321"`
+}
+
+type OTPResponse struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	ResendAt  time.Time `json:"resendAt"`
+}
+
+type VerifyOTPRequest struct {
+	Phone string `json:"phone" binding:"required,min=1,max=20" example:"0987654321"`
+	Code  string `json:"code" binding:"required,len=6,numeric" example:"123456"`
+}
+
+type VerifyOTPResponse struct {
+	VerificationToken string    `json:"verificationToken"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required" example:"a12345678"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6,max=50" example:"b12345678"`
+}
+
+type ResetPasswordRequest struct {
+	VerificationToken string `json:"verificationToken" binding:"required"`
+	NewPassword       string `json:"newPassword" binding:"required,min=6,max=50" example:"b12345678"`
+}
+
+type RegisterRequest struct {
+	VerificationToken string `json:"verificationToken" binding:"required"`
+	Name              string `json:"name" binding:"required,min=1,max=20" example:"testdemo001"`
+	Password          string `json:"password" binding:"required,min=6,max=50" example:"a12345678"`
 }
 
 // Login godoc
 // @Summary      User login
-// @Description  Login with phone and password
+// @Description  Login with phone and password. Repeated failures for a phone or from an IP address first require waiting and then lock logins temporarily.
+// @Description  When two-factor authentication is enabled, or required but not set up yet, 202 returns a challenge token for /auth/2fa/verify or /auth/2fa/enroll instead of tokens
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request body LoginRequest true "Login credentials"
 // @Success      200  {object}  LoginResponse
+// @Success      202  {object}  TwoFactorChallengeResponse
 // @Failure      400  {object}  map[string]string
 // @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
 // @Router       /api/v1/auth [post]
 func (h *AuthHandler) userLogin(c *gin.Context) {
 	var req LoginRequest
@@ -45,14 +124,456 @@ func (h *AuthHandler) userLogin(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.userService.Login(req.Phone, req.Password)
+	// 以帳號密碼登入的使用者都屬於平台營運商，營運商玩家經由營運商 session 進入遊戲
+	operatorID := h.operatorService.PlatformOperatorID()
+	ip := c.ClientIP()
+	if err := h.loginGuard.Check(operatorID, req.Phone, ip); err != nil {
+		otpError(c, err)
+		return
+	}
+
+	user, err := h.userService.Login(operatorID, req.Phone, req.Password)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrInvalidPassword):
+		if err := h.loginGuard.Fail(operatorID, req.Phone, ip); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errInvalidCredentials})
+		return
+	case errors.Is(err, service.ErrUserBlocked):
+		// 只有密碼正確時才會得知帳號已停用
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 需要兩步驟驗證時先返回只能用於驗證或設定的 token，失敗計數在驗證通過後才清除
+	if user.TwoFactorEnabled() || user.RequiresTwoFactor() {
+		h.twoFactorChallenge(c, user)
+		return
+	}
+	if err := h.loginGuard.Succeed(operatorID, req.Phone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.tokenService.Issue(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := newLoginResponse(pair)
+	response.User = user
+	c.JSON(http.StatusOK, response)
+}
+
+// twoFactorChallenge 回應兩步驟驗證用的 token，尚未設定的管理者只能用來完成設定
+func (h *AuthHandler) twoFactorChallenge(c *gin.Context, user *entity.User) {
+	scope := token.ScopeTwoFactor
+	if !user.TwoFactorEnabled() {
+		scope = token.ScopeTwoFactorEnroll
+	}
+
+	challenge, expiresAt, err := h.tokenService.IssueChallenge(user, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, TwoFactorChallengeResponse{
+		TwoFactorRequired:  true,
+		EnrollmentRequired: scope == token.ScopeTwoFactorEnroll,
+		ChallengeToken:     challenge,
+		ExpiresAt:          expiresAt,
+	})
+}
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchange a refresh token for a new access token and refresh token. The old refresh token can no longer be used; reusing it revokes every token issued from the same login
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body RefreshRequest true "Refresh token"
+// @Success      200  {object}  LoginResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /api/v1/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.tokenService.Refresh(req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrUserBlocked) || errors.Is(err, service.ErrTwoFactorRequired) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(pair))
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revoke the current access token and, when given, its refresh token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request body LogoutRequest false "Refresh token to revoke"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /api/v1/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claims, ok := getTokenClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.tokenService.Revoke(claims, req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS godoc
+// @Summary      Token verification keys
+// @Description  Public keys for verifying access tokens, selected by the kid header of the token. Keys being rotated out stay listed until the tokens they signed expire
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Router       /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenService.JWKS())
+}
+
+// RequestRegistrationOTP godoc
+// @Summary      Request registration code
+// @Description  Send a verification code by SMS to a phone that is not registered yet. Requesting a new code invalidates the previous one
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body OTPRequest true "Phone to verify"
+// @Success      202  {object}  OTPResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
+// @Router       /api/v1/auth/register/otp [post]
+func (h *AuthHandler) RequestRegistrationOTP(c *gin.Context) {
+	var req OTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := h.otpService.Request(h.operatorService.PlatformOperatorID(), req.Phone, entity.VerificationPurposeRegister)
+	if err != nil {
+		otpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, OTPResponse{
+		ExpiresAt: challenge.ExpiresAt,
+		ResendAt:  challenge.ResendAt,
+	})
+}
+
+// VerifyRegistrationOTP godoc
+// @Summary      Verify registration code
+// @Description  Check the code sent by SMS and return a verification token for completing the registration
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body VerifyOTPRequest true "Phone and code"
+// @Success      200  {object}  VerifyOTPResponse
+// @Failure      400  {object}  map[string]string
+// @Router       /api/v1/auth/register/verify [post]
+func (h *AuthHandler) VerifyRegistrationOTP(c *gin.Context) {
+	var req VerifyOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	verification, err := h.otpService.Verify(h.operatorService.PlatformOperatorID(), req.Phone, entity.VerificationPurposeRegister, req.Code)
+	if err != nil {
+		otpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VerifyOTPResponse{
+		VerificationToken: verification.Token,
+		ExpiresAt:         verification.ExpiresAt,
+	})
+}
+
+// Register godoc
+// @Summary      Register
+// @Description  Create a player account for the verified phone and log in
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body RegisterRequest true "Verification token and account details"
+// @Success      201  {object}  LoginResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 自行註冊的玩家屬於平台營運商
+	operatorID := h.operatorService.PlatformOperatorID()
+	phone, err := h.otpService.Consume(operatorID, entity.VerificationPurposeRegister, req.VerificationToken)
+	if err != nil {
+		otpError(c, err)
+		return
+	}
+
+	user, err := h.userService.CreateUser(operatorID, req.Name, phone, req.Password)
+	if err != nil {
+		otpError(c, err)
+		return
+	}
+
+	pair, err := h.tokenService.Issue(user)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := newLoginResponse(pair)
+	response.User = user
+	c.JSON(http.StatusCreated, response)
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Set a new password after checking the current one. Wrong current passwords count as login failures and are throttled the same way. Every refresh token of the user is revoked, so other devices must log in again; new tokens for this device are returned
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request body ChangePasswordRequest true "Current and new password"
+// @Success      200  {object}  LoginResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
+// @Router       /api/v1/auth/password [put]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := getTokenClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return
+	}
+
+	user, err := h.userService.GetUser(claims.OperatorID, userID)
+	if errors.Is(err, service.ErrUserNotFound) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 錯誤的目前密碼與登入失敗一同計數，避免以已登入的 token 無限次猜測密碼
+	ip := c.ClientIP()
+	if err := h.loginGuard.Check(user.OperatorID, user.Phone, ip); err != nil {
+		otpError(c, err)
+		return
+	}
+
+	err = h.userService.ChangePassword(claims.OperatorID, userID, req.CurrentPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, service.ErrInvalidPassword):
+		if err := h.loginGuard.Fail(user.OperatorID, user.Phone, ip); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrSamePassword):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.loginGuard.Succeed(user.OperatorID, user.Phone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 目前的 access token 也一併撤銷，以新發出的 token 繼續使用
+	if err := h.tokenService.Revoke(claims, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	pair, err := h.tokenService.Issue(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(pair))
+}
+
+// ForgotPassword godoc
+// @Summary      Request password reset code
+// @Description  Send a verification code by SMS for resetting the password. The response is the same whether or not the phone is registered
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body OTPRequest true "Registered phone"
+// @Success      202  {object}  OTPResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
+// @Router       /api/v1/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req OTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, LoginResponse{
-		Token: token,
-		User:  user,
+	challenge, err := h.otpService.Request(h.operatorService.PlatformOperatorID(), req.Phone, entity.VerificationPurposeResetPassword)
+	if err != nil {
+		otpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, OTPResponse{
+		ExpiresAt: challenge.ExpiresAt,
+		ResendAt:  challenge.ResendAt,
 	})
 }
+
+// VerifyPasswordResetOTP godoc
+// @Summary      Verify password reset code
+// @Description  Check the code sent by SMS and return a verification token for resetting the password
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body VerifyOTPRequest true "Phone and code"
+// @Success      200  {object}  VerifyOTPResponse
+// @Failure      400  {object}  map[string]string
+// @Router       /api/v1/auth/password/verify [post]
+func (h *AuthHandler) VerifyPasswordResetOTP(c *gin.Context) {
+	var req VerifyOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	verification, err := h.otpService.Verify(h.operatorService.PlatformOperatorID(), req.Phone, entity.VerificationPurposeResetPassword, req.Code)
+	if err != nil {
+		otpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VerifyOTPResponse{
+		VerificationToken: verification.Token,
+		ExpiresAt:         verification.ExpiresAt,
+	})
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password for the verified phone. Every refresh token of the user is revoked
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body ResetPasswordRequest true "Verification token and new password"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Router       /api/v1/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	operatorID := h.operatorService.PlatformOperatorID()
+	phone, err := h.otpService.Consume(operatorID, entity.VerificationPurposeResetPassword, req.VerificationToken)
+	if err != nil {
+		otpError(c, err)
+		return
+	}
+
+	if _, err := h.userService.ResetPassword(operatorID, phone, req.NewPassword); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			err = service.ErrInvalidVerification
+		}
+		otpError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// otpError 將驗證碼及登入頻率限制相關的錯誤轉換為 HTTP 回應
+func otpError(c *gin.Context, err error) {
+	var throttled *service.ThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(int(throttled.RetryAfter.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPhoneRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPhone),
+		errors.Is(err, service.ErrInvalidOTP),
+		errors.Is(err, service.ErrInvalidVerification):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func newLoginResponse(pair *service.TokenPair) LoginResponse {
+	return LoginResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}
+}
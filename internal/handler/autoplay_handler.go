@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/service"
+	"passontw-slot-game/pkg/money"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AutoplayRequest struct {
+	Spins          int    `json:"spins" binding:"required,min=1" example:"10"` // 上限由營運商設定
+	BetAmount      string `json:"betAmount" binding:"required" example:"1.00"`
+	Currency       string `json:"currency,omitempty" binding:"omitempty,alpha,max=10" example:"TWD"`
+	StopOnWinAbove string `json:"stopOnWinAbove" example:"50.00"`
+	LossLimit      string `json:"lossLimit" example:"20.00"`
+	StopOnFeature  bool   `json:"stopOnFeature" example:"true"`
+	SymbolFormat   string `json:"symbolFormat" binding:"omitempty,oneof=int code" example:"code"`
+}
+
+type AutoplayResponse struct {
+	Success    bool   `json:"success" example:"true"`
+	AutoplayID string `json:"autoplayId" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	Spins      int    `json:"spins" example:"10"`
+}
+
+type AutoplayUpdateMessage struct {
+	AutoplayID string        `json:"autoplayId"`
+	Spin       int           `json:"spin"`
+	NetResult  string        `json:"netResult" example:"-3.50"`
+	Result     *SpinResponse `json:"result,omitempty"`
+	StopReason string        `json:"stopReason,omitempty"`
+}
+
+type AutoplayHandler struct {
+	autoplayService service.AutoplayService
+	wsHandler       *WebSocketHandler
+	config          *config.Config
+}
+
+func NewAutoplayHandler(autoplayService service.AutoplayService, wsHandler *WebSocketHandler, cfg *config.Config) *AutoplayHandler {
+	return &AutoplayHandler{
+		autoplayService: autoplayService,
+		wsHandler:       wsHandler,
+		config:          cfg,
+	}
+}
+
+// StartAutoplay godoc
+// @Summary      Start autoplay
+// @Description  Start a server-driven series of spins; each result is pushed over the /ws connection
+// @Tags         game
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request body AutoplayRequest true "Autoplay settings and stop conditions"
+// @Success      202  {object}  AutoplayResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/game/autoplay [post]
+func (h *AutoplayHandler) StartAutoplay(c *gin.Context) {
+	var req AutoplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	userID, ok := getUserID(c)
+	operatorID, hasOperator := getOperatorID(c)
+	if !ok || !hasOperator {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	settings, err := h.newSettings(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: err.Error(),
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	encoder := symbolEncoder{byCode: req.SymbolFormat == "code"}
+	notify := func(userID int, update service.AutoplayUpdate) {
+		h.pushUpdate(userID, update, encoder)
+	}
+
+	id, err := h.autoplayService.Start(operatorID, userID, settings, notify)
+	if err != nil {
+		status := autoplayErrorStatus(err)
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, AutoplayResponse{
+		Success:    true,
+		AutoplayID: id,
+		Spins:      req.Spins,
+	})
+}
+
+// CancelAutoplay godoc
+// @Summary      Cancel autoplay
+// @Description  Cancel the running autoplay of the current user
+// @Tags         game
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  MessageResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/game/autoplay [delete]
+func (h *AutoplayHandler) CancelAutoplay(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	if err := h.autoplayService.Cancel(userID); err != nil {
+		status := autoplayErrorStatus(err)
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "autoplay cancelled"})
+}
+
+// pushUpdate 透過 WebSocket 推送自動旋轉結果
+func (h *AutoplayHandler) pushUpdate(userID int, update service.AutoplayUpdate, encoder symbolEncoder) {
+	content := AutoplayUpdateMessage{
+		AutoplayID: update.ID,
+		Spin:       update.Spin,
+		NetResult:  update.NetResult.String(),
+		StopReason: string(update.StopReason),
+	}
+	if update.Result != nil {
+		response := newSpinResponse(update.Result, encoder)
+		content.Result = &response
+	}
+
+	h.wsHandler.SendToUser(strconv.Itoa(userID), Message{
+		Type:    "autoplay",
+		Content: content,
+	})
+}
+
+// newSettings 解析請求中的金額，停止條件留空代表不啟用
+func (h *AutoplayHandler) newSettings(req AutoplayRequest) (service.AutoplaySettings, error) {
+	currency := requestCurrency(req.Currency, h.config.Currency.Default)
+	settings := service.AutoplaySettings{
+		Spins:          req.Spins,
+		StopOnWinAbove: money.Zero(currency),
+		LossLimit:      money.Zero(currency),
+		StopOnFeature:  req.StopOnFeature,
+	}
+
+	var err error
+	if settings.BetAmount, err = money.Parse(req.BetAmount, currency); err != nil || !settings.BetAmount.IsPositive() {
+		return settings, errors.New("invalid bet amount")
+	}
+	if req.StopOnWinAbove != "" {
+		if settings.StopOnWinAbove, err = money.Parse(req.StopOnWinAbove, currency); err != nil || settings.StopOnWinAbove.IsNegative() {
+			return settings, errors.New("invalid stopOnWinAbove amount")
+		}
+	}
+	if req.LossLimit != "" {
+		if settings.LossLimit, err = money.Parse(req.LossLimit, currency); err != nil || settings.LossLimit.IsNegative() {
+			return settings, errors.New("invalid lossLimit amount")
+		}
+	}
+	return settings, nil
+}
+
+func autoplayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAutoplayDisabled), errors.Is(err, service.ErrGameDisabled), errors.Is(err, service.ErrUserBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrAutoplayRunning):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrAutoplayNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCurrencyNotSupported), errors.Is(err, service.ErrBetNotAllowed), errors.Is(err, service.ErrAutoplayTooManySpins):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
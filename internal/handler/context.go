@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/token"
+	"passontw-slot-game/pkg/money"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getUserID 從 context 取得 AuthMiddleware 設置的使用者 ID
+func getUserID(c *gin.Context) (int, bool) {
+	return contextInt(c, "userId")
+}
+
+// getOperatorID 從 context 取得 AuthMiddleware 或 OperatorAuth 設置的營運商 ID
+func getOperatorID(c *gin.Context) (int, bool) {
+	return contextInt(c, "operatorId")
+}
+
+// getTargetOperatorID 返回管理介面操作的營運商，平台管理者經由 /admin/operators/:id 指定，否則為管理者所屬的營運商
+func getTargetOperatorID(c *gin.Context) (int, bool) {
+	if operatorID, ok := contextInt(c, "targetOperatorId"); ok {
+		return operatorID, true
+	}
+	return getOperatorID(c)
+}
+
+// resourceParam 返回管理路由中的資源 ID，/admin/operators/:id 之下 :id 為營運商，資源 ID 改以 name 參數傳入
+func resourceParam(c *gin.Context, name string) string {
+	if _, scoped := c.Get("targetOperatorId"); scoped {
+		return c.Param(name)
+	}
+	return c.Param("id")
+}
+
+// contextInt 讀取 context 中的整數，使用者 ID 以 token subject 的字串形式存放
+func contextInt(c *gin.Context, key string) (int, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		return 0, false
+	}
+
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case string:
+		id, err := strconv.Atoi(v)
+		return id, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// requestCurrency 返回請求指定的貨幣，未指定時使用預設貨幣
+func requestCurrency(value, fallback string) money.Currency {
+	if value == "" {
+		value = fallback
+	}
+	return money.Currency(strings.ToUpper(value))
+}
+
+// validCurrency 檢查貨幣代碼是否為 1 到 10 個大寫英文字母
+func validCurrency(currency money.Currency) bool {
+	if len(currency) == 0 || len(currency) > 10 {
+		return false
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// getOperator 從 context 取得 OperatorAuth 設置的營運商
+func getOperator(c *gin.Context) (*entity.Operator, bool) {
+	value, exists := c.Get("operator")
+	if !exists {
+		return nil, false
+	}
+	operator, ok := value.(*entity.Operator)
+	return operator, ok
+}
+
+// getTokenClaims 從 context 取得 AuthMiddleware 驗證過的 token claims
+func getTokenClaims(c *gin.Context) (*token.Claims, bool) {
+	value, exists := c.Get("tokenClaims")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*token.Claims)
+	return claims, ok
+}
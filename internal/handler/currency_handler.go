@@ -0,0 +1,422 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"passontw-slot-game/internal/service"
+	"passontw-slot-game/pkg/money"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BetLimitInfo struct {
+	Currency  string   `json:"currency" example:"TWD"`
+	MinBet    string   `json:"minBet" example:"1.00"`
+	MaxBet    string   `json:"maxBet" example:"1000.00"`
+	BetLevels []string `json:"betLevels" example:"1.00,2.00,5.00"`
+}
+
+type BetLimitsResponse struct {
+	Success bool           `json:"success" example:"true"`
+	GameID  string         `json:"gameId" example:"classic"`
+	Limits  []BetLimitInfo `json:"limits"`
+}
+
+type BetLimitRequest struct {
+	MinBet    string   `json:"minBet" binding:"required" example:"1.00"`
+	MaxBet    string   `json:"maxBet" binding:"required" example:"1000.00"`
+	BetLevels []string `json:"betLevels" example:"1.00,2.00,5.00"`
+}
+
+type ExchangeRateInfo struct {
+	Currency  string    `json:"currency" example:"USD"`
+	Rate      string    `json:"rate" example:"32.5"`
+	UpdatedAt time.Time `json:"updatedAt" example:"2025-02-16T16:05:00.763995Z"`
+}
+
+type ExchangeRatesResponse struct {
+	Success      bool               `json:"success" example:"true"`
+	BaseCurrency string             `json:"baseCurrency" example:"TWD"`
+	Rates        []ExchangeRateInfo `json:"rates"`
+}
+
+type ExchangeRateRequest struct {
+	Rate string `json:"rate" binding:"required" example:"32.5"`
+}
+
+type CurrencySummaryInfo struct {
+	Currency string `json:"currency" example:"USD"`
+	Rounds   int64  `json:"rounds" example:"120"`
+	Bet      string `json:"bet" example:"240.00"`
+	Win      string `json:"win" example:"210.50"`
+	GGR      string `json:"ggr" example:"29.50"`
+	BaseBet  string `json:"baseBet,omitempty" example:"7800.00"`
+	BaseWin  string `json:"baseWin,omitempty" example:"6841.25"`
+	BaseGGR  string `json:"baseGgr,omitempty" example:"958.75"`
+	HasRate  bool   `json:"hasRate" example:"true"`
+}
+
+type SummaryReportResponse struct {
+	Success      bool                  `json:"success" example:"true"`
+	From         time.Time             `json:"from" example:"2025-02-16T00:00:00Z"`
+	To           time.Time             `json:"to" example:"2025-02-17T00:00:00Z"`
+	BaseCurrency string                `json:"baseCurrency" example:"TWD"`
+	Currencies   []CurrencySummaryInfo `json:"currencies"`
+	TotalBet     string                `json:"totalBet" example:"7800.00"`
+	TotalWin     string                `json:"totalWin" example:"6841.25"`
+	TotalGGR     string                `json:"totalGgr" example:"958.75"`
+}
+
+type CurrencyHandler struct {
+	currencyService service.CurrencyService
+	reportService   service.ReportService
+}
+
+func NewCurrencyHandler(currencyService service.CurrencyService, reportService service.ReportService) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencyService: currencyService,
+		reportService:   reportService,
+	}
+}
+
+// GetBetLimits godoc
+// @Summary      Get bet limits
+// @Description  Get the default supported currencies of a game with their min/max bet and bet levels; operators may override them
+// @Tags         game
+// @Produce      json
+// @Param        id path string true "Game ID"
+// @Success      200  {object}  BetLimitsResponse
+// @Router       /api/v1/games/{id}/bet-limits [get]
+func (h *CurrencyHandler) GetBetLimits(c *gin.Context) {
+	gameID := c.Param("id")
+	limits, err := h.currencyService.ListBetLimits(service.SharedBetLimits, gameID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get bet limits",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, BetLimitsResponse{
+		Success: true,
+		GameID:  gameID,
+		Limits:  newBetLimitInfos(limits),
+	})
+}
+
+func newBetLimitInfos(limits []service.BetLimits) []BetLimitInfo {
+	infos := make([]BetLimitInfo, 0, len(limits))
+	for _, limit := range limits {
+		info := BetLimitInfo{
+			Currency:  string(limit.Currency),
+			MinBet:    limit.MinBet.String(),
+			MaxBet:    limit.MaxBet.String(),
+			BetLevels: make([]string, 0, len(limit.BetLevels)),
+		}
+		for _, level := range limit.BetLevels {
+			info.BetLevels = append(info.BetLevels, level.String())
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// SetBetLimits godoc
+// @Summary      Set bet limits
+// @Description  Create or replace the default bet limits of a game in one currency; an empty betLevels allows any amount between min and max bet
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path  string           true  "Game ID"
+// @Param        currency path  string           true  "Currency code"
+// @Param        request  body  BetLimitRequest  true  "Bet limits"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/games/{id}/bet-limits/{currency} [put]
+func (h *CurrencyHandler) SetBetLimits(c *gin.Context) {
+	h.setBetLimits(c, service.SharedBetLimits, c.Param("id"))
+}
+
+// SetOperatorBetLimits godoc
+// @Summary      Set operator bet limits
+// @Description  Override the default bet limits of a game in one currency for one operator
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path  int              true  "Operator ID"
+// @Param        gameId   path  string           true  "Game ID"
+// @Param        currency path  string           true  "Currency code"
+// @Param        request  body  BetLimitRequest  true  "Bet limits"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/operators/{id}/games/{gameId}/bet-limits/{currency} [put]
+func (h *CurrencyHandler) SetOperatorBetLimits(c *gin.Context) {
+	operatorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || operatorID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid operator id",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+	h.setBetLimits(c, operatorID, c.Param("gameId"))
+}
+
+func (h *CurrencyHandler) setBetLimits(c *gin.Context, operatorID int, gameID string) {
+	var req BetLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	currency := requestCurrency(c.Param("currency"), "")
+	if !validCurrency(currency) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid currency",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	limits := service.BetLimits{
+		OperatorID: operatorID,
+		GameID:     gameID,
+		Currency:   currency,
+		BetLevels:  make([]money.Money, 0, len(req.BetLevels)),
+	}
+
+	var err error
+	if limits.MinBet, err = money.Parse(req.MinBet, currency); err == nil {
+		limits.MaxBet, err = money.Parse(req.MaxBet, currency)
+	}
+	for _, value := range req.BetLevels {
+		if err != nil {
+			break
+		}
+		var level money.Money
+		level, err = money.Parse(value, currency)
+		limits.BetLevels = append(limits.BetLevels, level)
+	}
+	if err == nil {
+		err = h.currencyService.SetBetLimits(limits)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if !errors.Is(err, service.ErrInvalidBetLimits) && !isMoneyError(err) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "bet limits updated"})
+}
+
+// GetExchangeRates godoc
+// @Summary      Get exchange rates
+// @Description  Get the locally-managed exchange rates used to convert reports to the base currency
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  ExchangeRatesResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/exchange-rates [get]
+func (h *CurrencyHandler) GetExchangeRates(c *gin.Context) {
+	rates, err := h.currencyService.ExchangeRates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get exchange rates",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	response := ExchangeRatesResponse{
+		Success:      true,
+		BaseCurrency: string(h.currencyService.BaseCurrency()),
+		Rates:        make([]ExchangeRateInfo, 0, len(rates)),
+	}
+	for _, rate := range rates {
+		response.Rates = append(response.Rates, ExchangeRateInfo{
+			Currency:  rate.Currency,
+			Rate:      rate.Rate,
+			UpdatedAt: rate.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetExchangeRate godoc
+// @Summary      Set exchange rate
+// @Description  Set how many units of the base currency one unit of the given currency is worth
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        currency path  string               true  "Currency code"
+// @Param        request  body  ExchangeRateRequest  true  "Exchange rate"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/exchange-rates/{currency} [put]
+func (h *CurrencyHandler) SetExchangeRate(c *gin.Context) {
+	var req ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	currency := requestCurrency(c.Param("currency"), "")
+	if !validCurrency(currency) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid currency",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.currencyService.SetExchangeRate(currency, req.Rate); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidExchangeRate) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "exchange rate updated"})
+}
+
+// GetSummaryReport godoc
+// @Summary      Get summary report
+// @Description  Aggregate the caller's operator's completed rounds per currency and in the base currency; from/to accept RFC3339 or YYYY-MM-DD and default to today
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        from query string false "Start of the period (inclusive)"
+// @Param        to   query string false "End of the period (exclusive)"
+// @Success      200  {object}  SummaryReportResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/reports/summary [get]
+func (h *CurrencyHandler) GetSummaryReport(c *gin.Context) {
+	operatorID, ok := getOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid operator",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+	h.summaryReport(c, operatorID)
+}
+
+// GetOperatorSummaryReport godoc
+// @Summary      Get operator summary report
+// @Description  Aggregate one operator's completed rounds per currency and in the base currency; from/to accept RFC3339 or YYYY-MM-DD and default to today
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path  int    true  "Operator ID"
+// @Param        from query string false "Start of the period (inclusive)"
+// @Param        to   query string false "End of the period (exclusive)"
+// @Success      200  {object}  SummaryReportResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/operators/{id}/reports/summary [get]
+func (h *CurrencyHandler) GetOperatorSummaryReport(c *gin.Context) {
+	operatorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || operatorID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid operator id",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+	h.summaryReport(c, operatorID)
+}
+
+func (h *CurrencyHandler) summaryReport(c *gin.Context, operatorID int) {
+	now := time.Now()
+	from, errFrom := parseReportTime(c.Query("from"), time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+	to, errTo := parseReportTime(c.Query("to"), now)
+	if errFrom != nil || errTo != nil || !from.Before(to) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid report period",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	summary, err := h.reportService.Summary(operatorID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to build report",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	response := SummaryReportResponse{
+		Success:      true,
+		From:         summary.From,
+		To:           summary.To,
+		BaseCurrency: string(summary.BaseCurrency),
+		Currencies:   make([]CurrencySummaryInfo, 0, len(summary.Currencies)),
+		TotalBet:     summary.TotalBet.String(),
+		TotalWin:     summary.TotalWin.String(),
+		TotalGGR:     summary.TotalGGR.String(),
+	}
+	for _, item := range summary.Currencies {
+		info := CurrencySummaryInfo{
+			Currency: string(item.Currency),
+			Rounds:   item.Rounds,
+			Bet:      item.Bet.String(),
+			Win:      item.Win.String(),
+			GGR:      item.GGR.String(),
+			HasRate:  item.HasRate,
+		}
+		if item.HasRate {
+			info.BaseBet = item.BaseBet.String()
+			info.BaseWin = item.BaseWin.String()
+			info.BaseGGR = item.BaseGGR.String()
+		}
+		response.Currencies = append(response.Currencies, info)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseReportTime 解析 RFC3339 或 YYYY-MM-DD 格式的時間，空字串時返回預設值
+func parseReportTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// isMoneyError 判斷是否為金額格式錯誤
+func isMoneyError(err error) bool {
+	return errors.Is(err, money.ErrInvalidAmount) || errors.Is(err, money.ErrTooManyDecimals) || errors.Is(err, money.ErrAmountOverflow)
+}
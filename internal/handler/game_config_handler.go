@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DraftResponse struct {
+	ID           int                   `json:"id" example:"1"`
+	GameID       string                `json:"gameId" example:"classic"`
+	Status       string                `json:"status" example:"draft"`
+	CreatedBy    int                   `json:"createdBy" example:"1"`
+	RevisionHash string                `json:"revisionHash,omitempty" example:"3f29c1e0a7b4"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	PublishedAt  *time.Time            `json:"publishedAt,omitempty"`
+	Definition   domain.GameDefinition `json:"definition"`
+}
+
+type RevisionResponse struct {
+	GameID       string `json:"gameId" example:"classic"`
+	RevisionHash string `json:"revisionHash" example:"3f29c1e0a7b4"`
+}
+
+type RevisionHistoryItem struct {
+	RevisionHash string    `json:"revisionHash" example:"3f29c1e0a7b4"`
+	ActivateAt   time.Time `json:"activateAt"`
+	Rollback     bool      `json:"rollback" example:"false"`
+}
+
+type PublishFailureResponse struct {
+	Error  string                   `json:"error" example:"game definition is invalid"`
+	Code   int                      `json:"code" example:"422"`
+	Report service.ValidationReport `json:"report"`
+}
+
+type GameConfigHandler struct {
+	gameConfigService service.GameConfigService
+}
+
+func NewGameConfigHandler(gameConfigService service.GameConfigService) *GameConfigHandler {
+	return &GameConfigHandler{
+		gameConfigService: gameConfigService,
+	}
+}
+
+// CreateDraft godoc
+// @Summary      Upload game definition draft
+// @Description  Store a new game definition as a draft; it does not affect live play until published
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Param        request body domain.GameDefinition true "Game definition"
+// @Success      201  {object}  DraftResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/games/{id}/drafts [post]
+func (h *GameConfigHandler) CreateDraft(c *gin.Context) {
+	var definition domain.GameDefinition
+	if err := c.ShouldBindJSON(&definition); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	draft, err := h.gameConfigService.CreateDraft(c.Param("id"), definition, userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newDraftResponse(draft))
+}
+
+// ListDrafts godoc
+// @Summary      List game definition drafts
+// @Description  List all drafts of a game, newest first
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Success      200  {array}   DraftResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/games/{id}/drafts [get]
+func (h *GameConfigHandler) ListDrafts(c *gin.Context) {
+	drafts, err := h.gameConfigService.ListDrafts(c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	response := make([]DraftResponse, 0, len(drafts))
+	for i := range drafts {
+		response = append(response, newDraftResponse(&drafts[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDraft godoc
+// @Summary      Get game definition draft
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Param        draftId path int true "Draft ID"
+// @Success      200  {object}  DraftResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/games/{id}/drafts/{draftId} [get]
+func (h *GameConfigHandler) GetDraft(c *gin.Context) {
+	draft, ok := h.loadDraft(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newDraftResponse(draft))
+}
+
+// ValidateDraft godoc
+// @Summary      Validate game definition draft
+// @Description  Check the draft for structural errors and preview its theoretical line RTP against the allowed band
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Param        draftId path int true "Draft ID"
+// @Success      200  {object}  service.ValidationReport
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/games/{id}/drafts/{draftId}/validate [post]
+func (h *GameConfigHandler) ValidateDraft(c *gin.Context) {
+	draft, ok := h.loadDraft(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.gameConfigService.Validate(draft.Definition))
+}
+
+// PublishDraft godoc
+// @Summary      Publish game definition draft
+// @Description  Validate the draft and atomically make it the live revision; the previous revision stays available for rollback
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Param        draftId path int true "Draft ID"
+// @Success      200  {object}  RevisionResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      422  {object}  PublishFailureResponse
+// @Router       /api/v1/admin/games/{id}/drafts/{draftId}/publish [post]
+func (h *GameConfigHandler) PublishDraft(c *gin.Context) {
+	draft, ok := h.loadDraft(c)
+	if !ok {
+		return
+	}
+
+	revision, err := h.gameConfigService.Publish(draft.GameID, draft.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrDefinitionInvalid) {
+			c.JSON(http.StatusUnprocessableEntity, PublishFailureResponse{
+				Error:  err.Error(),
+				Code:   http.StatusUnprocessableEntity,
+				Report: h.gameConfigService.Validate(draft.Definition),
+			})
+			return
+		}
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RevisionResponse{
+		GameID:       revision.Definition.GameID,
+		RevisionHash: revision.Hash,
+	})
+}
+
+// ListRevisions godoc
+// @Summary      List revision history
+// @Description  List the activation history of a game's revisions, newest first
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Success      200  {array}   RevisionHistoryItem
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/games/{id}/revisions [get]
+func (h *GameConfigHandler) ListRevisions(c *gin.Context) {
+	activations, err := h.gameConfigService.History(c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	response := make([]RevisionHistoryItem, 0, len(activations))
+	for _, activation := range activations {
+		response = append(response, RevisionHistoryItem{
+			RevisionHash: activation.RevisionHash,
+			ActivateAt:   activation.ActivateAt,
+			Rollback:     activation.Rollback,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Rollback godoc
+// @Summary      Roll back to previous revision
+// @Description  Reactivate the revision that was live before the current one; repeated rollbacks keep stepping back through the activation history
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Success      200  {object}  RevisionResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/admin/games/{id}/rollback [post]
+func (h *GameConfigHandler) Rollback(c *gin.Context) {
+	revision, err := h.gameConfigService.Rollback(c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RevisionResponse{
+		GameID:       revision.Definition.GameID,
+		RevisionHash: revision.Hash,
+	})
+}
+
+func (h *GameConfigHandler) loadDraft(c *gin.Context) (*service.Draft, bool) {
+	draftID, err := strconv.Atoi(c.Param("draftId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid draft id",
+			Code:  http.StatusBadRequest,
+		})
+		return nil, false
+	}
+
+	draft, err := h.gameConfigService.GetDraft(c.Param("id"), draftID)
+	if err != nil {
+		h.respondError(c, err)
+		return nil, false
+	}
+	return draft, true
+}
+
+func (h *GameConfigHandler) respondError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrDraftNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrDraftGameMismatch):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrDraftPublished), errors.Is(err, service.ErrNoPreviousRevision):
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, ErrorResponse{
+		Error: err.Error(),
+		Code:  status,
+	})
+}
+
+func newDraftResponse(draft *service.Draft) DraftResponse {
+	response := DraftResponse{
+		ID:          draft.ID,
+		GameID:      draft.GameID,
+		Status:      draft.Status,
+		CreatedBy:   draft.CreatedBy,
+		CreatedAt:   draft.CreatedAt,
+		PublishedAt: draft.PublishedAt,
+		Definition:  draft.Definition,
+	}
+	if draft.RevisionHash != nil {
+		response.RevisionHash = *draft.RevisionHash
+	}
+	return response
+}
@@ -1,31 +1,104 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"passontw-slot-game/internal/config"
 	"passontw-slot-game/internal/domain"
 	"passontw-slot-game/internal/domain/models"
 	"passontw-slot-game/internal/service"
+	"passontw-slot-game/pkg/money"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type SpinRequest struct {
-	BetAmount float64 `json:"betAmount" binding:"required,gt=0" example:"1.0"`
+	BetAmount string `json:"betAmount" binding:"required" example:"1.00"`
+	Currency  string `json:"currency,omitempty" binding:"omitempty,alpha,max=10" example:"TWD"`
+	RoundID   string `json:"roundId,omitempty" binding:"omitempty,max=64" example:"b7c1e6a4-6a0e-4f0c-9a57-1f2d0c3e9b11"`
+}
+
+type FruitActionRequest struct {
+	Columns []int `json:"columns" example:"0,2" swaggertype:"array,integer"`
 }
 
 type SpinResponse struct {
-	Success      bool              `json:"success" example:"true"`
-	Board        [][]int           `json:"board" swaggertype:"array,array,integer"`
-	WinAmount    float64           `json:"winAmount" example:"10.5"`
-	TotalLines   int               `json:"totalLines" example:"2"`
-	WinningLines []WinningLineInfo `json:"winningLines"`
+	Success            bool              `json:"success" example:"true"`
+	RoundID            string            `json:"roundId" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	Board              interface{}       `json:"board" swaggertype:"array,array,integer"`
+	Currency           string            `json:"currency" example:"TWD"`
+	WinAmount          string            `json:"winAmount" example:"10.50"`
+	TotalLines         int               `json:"totalLines" example:"2"`
+	WinningLines       []WinningLineInfo `json:"winningLines"`
+	Feature            string            `json:"feature,omitempty" example:"free_spins"`
+	FreeSpinsRemaining int               `json:"freeSpinsRemaining" example:"0"`
+	RespinsRemaining   int               `json:"respinsRemaining" example:"0"`
+	Coins              []CoinInfo        `json:"coins,omitempty"`
+	HoldAvailable      bool              `json:"holdAvailable" example:"false"`
+	NudgesAvailable    int               `json:"nudgesAvailable" example:"0"`
+	RoundWinAmount     string            `json:"roundWinAmount" example:"10.50"`
+	Balance            string            `json:"balance" example:"990.50"`
+	RoundComplete      bool              `json:"roundComplete" example:"true"`
+	MaxWinReached      bool              `json:"maxWinReached" example:"false"`
 }
 
 type WinningLineInfo struct {
-	Type     string  `json:"type" example:"Horizontal"`
-	Position int     `json:"position" example:"1"`
-	Symbols  []int   `json:"symbols" swaggertype:"array,integer"`
-	Payout   float64 `json:"payout" example:"5.0"`
+	Type     string      `json:"type" example:"Horizontal"`
+	Position int         `json:"position" example:"1"`
+	Symbols  interface{} `json:"symbols" swaggertype:"array,integer"`
+	Payout   string      `json:"payout" example:"5.00"`
+}
+
+type OpenRoundsResponse struct {
+	Success bool           `json:"success" example:"true"`
+	Rounds  []SpinResponse `json:"rounds"`
+}
+
+type ReplayResponse struct {
+	Success       bool          `json:"success" example:"true"`
+	RoundID       string        `json:"roundId" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	UserID        int           `json:"userId" example:"1"`
+	RevisionHash  string        `json:"revisionHash" example:"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"`
+	Status        string        `json:"status" example:"completed"`
+	Currency      string        `json:"currency" example:"TWD"`
+	BetAmount     string        `json:"betAmount" example:"1.00"`
+	TotalWin      string        `json:"totalWin" example:"10.50"`
+	MaxWinReached bool          `json:"maxWinReached" example:"false"`
+	AutoCompleted bool          `json:"autoCompleted" example:"false"`
+	CreatedAt     time.Time     `json:"createdAt" example:"2025-02-16T16:05:00.763995Z"`
+	Events        []ReplayEvent `json:"events"`
+}
+
+type ReplayEvent struct {
+	Seq           int               `json:"seq" example:"1"`
+	Type          string            `json:"type" example:"spin"`
+	Board         interface{}       `json:"board" swaggertype:"array,array,integer"`
+	WinAmount     string            `json:"winAmount" example:"10.50"`
+	WinningLines  []WinningLineInfo `json:"winningLines"`
+	Coins         []CoinInfo        `json:"coins,omitempty"`
+	Balance       string            `json:"balance" example:"989.50"` // 此步驟結算後的錢包餘額，派彩完成前為空字串
+	MaxWinReached bool              `json:"maxWinReached,omitempty" example:"false"`
+	Text          string            `json:"text,omitempty" example:"┌───┬───┬───┐ ..."`
+}
+
+type SymbolsResponse struct {
+	Success bool                 `json:"success" example:"true"`
+	GameID  string               `json:"gameId" example:"classic"`
+	Symbols []SymbolMetaResponse `json:"symbols"`
+}
+
+type SymbolMetaResponse struct {
+	domain.SymbolMeta
+	DisplayName string `json:"displayName" example:"Cherry"`
+}
+
+type CoinInfo struct {
+	Row     int    `json:"row" example:"0"`
+	Col     int    `json:"col" example:"2"`
+	Value   string `json:"value" example:"5.00"`
+	Jackpot string `json:"jackpot,omitempty" example:"mini"`
 }
 
 type GameResponse struct {
@@ -45,25 +118,32 @@ type ErrorResponse struct {
 type GameHandler struct {
 	gameService service.GameService
 	checker     *service.Checker
+	config      *config.Config
 }
 
-func NewGameHandler(gameService service.GameService, checker *service.Checker) *GameHandler {
+func NewGameHandler(gameService service.GameService, checker *service.Checker, cfg *config.Config) *GameHandler {
 	return &GameHandler{
 		gameService: gameService,
 		checker:     checker,
+		config:      cfg,
 	}
 }
 
 // GetGameSpin godoc
 // @Summary      Get Game Spin Result
-// @Description  Spin the slot game with bet amount and get result
+// @Description  Spin the slot game with bet amount and get result.
+// @Description  A retried request with the same Idempotency-Key header (or roundId) returns the original result.
 // @Tags         game
 // @Accept       json
 // @Produce      json
 // @Security     Bearer
+// @Param        Idempotency-Key header string false "Client-supplied round key"
+// @Param        symbolFormat query string false "Set to code to encode symbols by code name"
 // @Param        request body SpinRequest true "Spin request with bet amount"
 // @Success      200  {object}  SpinResponse
 // @Failure      400  {object}  ErrorResponse
+// @Failure      402  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
 // @Router       /api/v1/game/spin [post]
 func (h *GameHandler) GetGameSpin(c *gin.Context) {
 	var req SpinRequest
@@ -75,30 +155,429 @@ func (h *GameHandler) GetGameSpin(c *gin.Context) {
 		return
 	}
 
-	board := h.gameService.GenerateBoard()
-	winResult := h.checker.CheckWin(board)
-	boardInt := convertBoardToInt(board)
-	totalWinAmount := winResult.Payout * req.BetAmount
+	betAmount, err := money.Parse(req.BetAmount, requestCurrency(req.Currency, h.config.Currency.Default))
+	if err != nil || !betAmount.IsPositive() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid bet amount",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	userID, ok := getUserID(c)
+	operatorID, hasOperator := getOperatorID(c)
+	if !ok || !hasOperator {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
 
-	winningLines := make([]WinningLineInfo, 0)
-	for _, line := range winResult.Lines {
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		idempotencyKey = req.RoundID
+	} else if req.RoundID != "" && req.RoundID != idempotencyKey {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Idempotency-Key header and roundId do not match",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+	if len(idempotencyKey) > 64 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Idempotency-Key is too long",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := h.gameService.Spin(operatorID, userID, betAmount, idempotencyKey)
+	if err != nil {
+		status := roundErrorStatus(err)
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	if result.Replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
+	c.JSON(http.StatusOK, newSpinResponse(result, newSymbolEncoder(c)))
+}
+
+// PlayRound godoc
+// @Summary      Play next feature spin
+// @Description  Play the next hold-and-spin respin or free spin of an open round
+// @Tags         game
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path      string  true  "Round ID"
+// @Success      200  {object}  SpinResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/game/rounds/{id}/spin [post]
+func (h *GameHandler) PlayRound(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	result, err := h.gameService.PlayRound(userID, c.Param("id"))
+	if err != nil {
+		status := roundErrorStatus(err)
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newSpinResponse(result, newSymbolEncoder(c)))
+}
+
+// GetOpenRounds godoc
+// @Summary      List open rounds
+// @Description  List the current user's unfinished rounds with their latest state so the client can resume them
+// @Tags         game
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  OpenRoundsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Router       /api/v1/game/rounds/open [get]
+func (h *GameHandler) GetOpenRounds(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	results, err := h.gameService.OpenRounds(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get open rounds",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	encoder := newSymbolEncoder(c)
+	rounds := make([]SpinResponse, 0, len(results))
+	for _, result := range results {
+		rounds = append(rounds, newSpinResponse(result, encoder))
+	}
+
+	c.JSON(http.StatusOK, OpenRoundsResponse{
+		Success: true,
+		Rounds:  rounds,
+	})
+}
+
+// GetSymbols godoc
+// @Summary      Get symbol catalogue
+// @Description  Get the symbol catalogue of a game, with display names resolved for the requested locale
+// @Tags         game
+// @Produce      json
+// @Param        id      path   string  true   "Game ID"
+// @Param        locale  query  string  false  "Locale of displayName (default: en)"
+// @Success      200  {object}  SymbolsResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/games/{id}/symbols [get]
+func (h *GameHandler) GetSymbols(c *gin.Context) {
+	gameID := c.Param("id")
+	catalogue, ok := domain.GetSymbolCatalogue(gameID)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "game not found",
+			Code:  http.StatusNotFound,
+		})
+		return
+	}
+
+	locale := c.DefaultQuery("locale", "en")
+	symbols := make([]SymbolMetaResponse, 0, len(catalogue))
+	for _, meta := range catalogue {
+		displayName, ok := meta.Names[locale]
+		if !ok {
+			displayName = meta.Names["en"]
+		}
+		symbols = append(symbols, SymbolMetaResponse{
+			SymbolMeta:  meta,
+			DisplayName: displayName,
+		})
+	}
+
+	c.JSON(http.StatusOK, SymbolsResponse{
+		Success: true,
+		GameID:  gameID,
+		Symbols: symbols,
+	})
+}
+
+// ReplayRound godoc
+// @Summary      Replay round
+// @Description  Return the full ordered event sequence of a round; format=text adds a text rendering of every board and win
+// @Tags         game
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path   string  true   "Round ID"
+// @Param        format  query  string  false  "Set to text to include text renderings"
+// @Success      200  {object}  ReplayResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/game/rounds/{id}/replay [get]
+func (h *GameHandler) ReplayRound(c *gin.Context) {
+	userID, ok := getUserID(c)
+	operatorID, hasOperator := getOperatorID(c)
+	if !ok || !hasOperator {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	round, err := h.gameService.GetRound(operatorID, c.Param("id"))
+	if err == nil && round.UserID != userID {
+		err = service.ErrRoundNotFound
+	}
+	if err != nil {
+		status := roundErrorStatus(err)
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.newReplayResponse(round, newSymbolEncoder(c), c.Query("format") == "text"))
+}
+
+// AdminReplayRound godoc
+// @Summary      Replay any round
+// @Description  Return the full ordered event sequence of any round of the caller's operator, for support investigations
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path   string  true   "Round ID"
+// @Param        format  query  string  false  "Set to text to include text renderings"
+// @Success      200  {object}  ReplayResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/rounds/{id}/replay [get]
+// @Router       /api/v1/admin/operators/{id}/rounds/{roundId}/replay [get]
+func (h *GameHandler) AdminReplayRound(c *gin.Context) {
+	operatorID, ok := getTargetOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid operator",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	round, err := h.gameService.GetRound(operatorID, resourceParam(c, "roundId"))
+	if err != nil {
+		status := roundErrorStatus(err)
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.newReplayResponse(round, newSymbolEncoder(c), c.Query("format") == "text"))
+}
+
+func (h *GameHandler) newReplayResponse(round *service.Round, encoder symbolEncoder, withText bool) ReplayResponse {
+	events := make([]ReplayEvent, 0, len(round.Events))
+	for _, event := range round.Events {
+		replayEvent := ReplayEvent{
+			Seq:           event.Seq,
+			Type:          event.Type,
+			Board:         encoder.board(event.Board),
+			WinAmount:     event.WinAmount.String(),
+			WinningLines:  convertWinningLines(event.Win, encoder),
+			Coins:         convertCoins(event.Coins),
+			MaxWinReached: event.MaxWinReached,
+		}
+		if event.Balance != nil {
+			replayEvent.Balance = event.Balance.String()
+		}
+		if withText {
+			replayEvent.Text = fmt.Sprintf("#%d %s\n%s%sWin amount: %s %s\n",
+				event.Seq, event.Type, event.Board.PrintBoard(), h.checker.FormatWinResult(event.Win),
+				event.WinAmount, event.WinAmount.Currency())
+		}
+		events = append(events, replayEvent)
+	}
+
+	return ReplayResponse{
+		Success:       true,
+		RoundID:       round.ID,
+		UserID:        round.UserID,
+		RevisionHash:  round.RevisionHash,
+		Status:        string(round.Status),
+		Currency:      string(round.BetAmount.Currency()),
+		BetAmount:     round.BetAmount.String(),
+		TotalWin:      round.TotalWin.String(),
+		MaxWinReached: round.MaxWinReached,
+		AutoCompleted: round.AutoCompleted,
+		CreatedAt:     round.CreatedAt,
+		Events:        events,
+	}
+}
+
+// HoldReels godoc
+// @Summary      Hold reels and respin
+// @Description  Lock the given columns of a fruit-machine round and respin the others
+// @Tags         game
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path  string              true  "Round ID"
+// @Param        request  body  FruitActionRequest  true  "Columns to hold"
+// @Success      200  {object}  SpinResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/game/rounds/{id}/hold [post]
+func (h *GameHandler) HoldReels(c *gin.Context) {
+	h.handleFruitAction(c, h.gameService.Hold)
+}
+
+// NudgeReels godoc
+// @Summary      Nudge reels
+// @Description  Shift each given column one position along its reel strip; an empty list declines the nudges
+// @Tags         game
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id       path  string              true  "Round ID"
+// @Param        request  body  FruitActionRequest  true  "Column of each nudge, in order"
+// @Success      200  {object}  SpinResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/game/rounds/{id}/nudge [post]
+func (h *GameHandler) NudgeReels(c *gin.Context) {
+	h.handleFruitAction(c, h.gameService.Nudge)
+}
+
+func (h *GameHandler) handleFruitAction(c *gin.Context, action func(userID int, roundID string, columns []int) (*service.SpinResult, error)) {
+	var req FruitActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	result, err := action(userID, c.Param("id"), req.Columns)
+	if err != nil {
+		status := roundErrorStatus(err)
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, newSpinResponse(result, newSymbolEncoder(c)))
+}
+
+func newSpinResponse(result *service.SpinResult, encoder symbolEncoder) SpinResponse {
+	round := result.Round
+
+	response := SpinResponse{
+		Success:            true,
+		RoundID:            round.ID,
+		Board:              encoder.board(result.Event.Board),
+		Currency:           string(round.BetAmount.Currency()),
+		WinAmount:          result.Event.WinAmount.String(),
+		TotalLines:         len(result.Event.Win.Lines),
+		WinningLines:       convertWinningLines(result.Event.Win, encoder),
+		Feature:            string(round.Feature),
+		FreeSpinsRemaining: round.FreeSpinsLeft,
+		RespinsRemaining:   round.RespinsLeft,
+		Coins:              convertCoins(result.Event.Coins),
+		RoundWinAmount:     round.TotalWin.String(),
+		Balance:            result.Balance.String(),
+		RoundComplete:      round.Status == service.RoundCompleted,
+		MaxWinReached:      round.MaxWinReached,
+	}
+	if round.Offer != nil {
+		response.HoldAvailable = round.Offer.Hold
+		response.NudgesAvailable = round.Offer.Nudges
+	}
+	return response
+}
+
+func convertWinningLines(win service.WinResult, encoder symbolEncoder) []WinningLineInfo {
+	winningLines := make([]WinningLineInfo, 0, len(win.Lines))
+	for _, line := range win.Lines {
 		winningLines = append(winningLines, WinningLineInfo{
 			Type:     line.Type,
 			Position: line.Position,
-			Symbols:  convertSymbolsToInt(line.Symbol, 3), // 3 symbols per line
-			Payout:   line.Payout * req.BetAmount,
+			Symbols:  encoder.symbols(line.Symbol, 3), // 3 symbols per line
+			Payout:   line.Payout.String(),
 		})
 	}
+	return winningLines
+}
 
-	response := SpinResponse{
-		Success:      true,
-		Board:        boardInt,
-		WinAmount:    totalWinAmount,
-		TotalLines:   len(winResult.Lines),
-		WinningLines: winningLines,
+func convertCoins(coinWins []service.CoinWin) []CoinInfo {
+	coins := make([]CoinInfo, 0, len(coinWins))
+	for _, coin := range coinWins {
+		coins = append(coins, CoinInfo{
+			Row:     coin.Row,
+			Col:     coin.Col,
+			Value:   coin.Amount.String(),
+			Jackpot: string(coin.Jackpot),
+		})
 	}
+	return coins
+}
 
-	c.JSON(http.StatusOK, response)
+func roundErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRoundNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRoundCompleted):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidAction), errors.Is(err, service.ErrInvalidBet),
+		errors.Is(err, service.ErrCurrencyNotSupported), errors.Is(err, service.ErrBetNotAllowed),
+		errors.Is(err, service.ErrExchangeRateNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrGameDisabled), errors.Is(err, service.ErrUserBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInsufficientFunds):
+		return http.StatusPaymentRequired
+	case errors.Is(err, service.ErrWalletUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrIdempotencyConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func convertBoardToInt(board models.Board) [][]int {
@@ -119,3 +598,50 @@ func convertSymbolsToInt(symbol domain.Symbol, count int) []int {
 	}
 	return result
 }
+
+func convertBoardToCode(board models.Board) [][]string {
+	result := make([][]string, 3)
+	for i := range result {
+		result[i] = make([]string, 3)
+		for j := range result[i] {
+			result[i][j] = board[i][j].Code()
+		}
+	}
+	return result
+}
+
+func convertSymbolsToCode(symbol domain.Symbol, count int) []string {
+	result := make([]string, count)
+	for i := range result {
+		result[i] = symbol.Code()
+	}
+	return result
+}
+
+// symbolEncoder 決定回應中的符號以整數或代碼名稱編碼
+type symbolEncoder struct {
+	byCode bool
+}
+
+// newSymbolEncoder 根據 symbolFormat 參數或 X-Symbol-Format header 選擇編碼方式，預設為整數
+func newSymbolEncoder(c *gin.Context) symbolEncoder {
+	format := c.Query("symbolFormat")
+	if format == "" {
+		format = c.GetHeader("X-Symbol-Format")
+	}
+	return symbolEncoder{byCode: format == "code"}
+}
+
+func (e symbolEncoder) board(board models.Board) interface{} {
+	if e.byCode {
+		return convertBoardToCode(board)
+	}
+	return convertBoardToInt(board)
+}
+
+func (e symbolEncoder) symbols(symbol domain.Symbol, count int) interface{} {
+	if e.byCode {
+		return convertSymbolsToCode(symbol, count)
+	}
+	return convertSymbolsToInt(symbol, count)
+}
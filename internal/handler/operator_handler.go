@@ -0,0 +1,432 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateSessionRequest struct {
+	PlayerID string `json:"playerId" binding:"required,max=100" example:"player-8842"`
+	Nickname string `json:"nickname" binding:"max=50" example:"Lucky8842"`
+	GameID   string `json:"gameId" example:"classic"`
+	Currency string `json:"currency" binding:"omitempty,alpha,max=10" example:"TWD"`
+	Language string `json:"language" binding:"max=10" example:"zh-TW"`
+	LobbyURL string `json:"lobbyUrl" binding:"omitempty,url,max=255" example:"https://casino.example.com/lobby"`
+}
+
+type SessionResponse struct {
+	Success   bool      `json:"success" example:"true"`
+	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	ExpiresAt time.Time `json:"expiresAt" example:"2025-02-16T16:20:00Z"`
+	LaunchURL string    `json:"launchUrl" example:"http://localhost:8080/play?currency=TWD&gameId=classic&token=eyJhbGciOiJIUzI1NiIs..."`
+}
+
+type CreateOperatorRequest struct {
+	Code string `json:"code" binding:"required,alphanum,max=50" example:"acme"`
+	Name string `json:"name" binding:"required,max=100" example:"Acme Casino"`
+}
+
+type OperatorResponse struct {
+	Success  bool            `json:"success" example:"true"`
+	Operator entity.Operator `json:"operator"`
+	APIKey   string          `json:"apiKey,omitempty" example:"sk_4f9c2a..."`
+}
+
+// JurisdictionSettingsRequest 營運商的司法管轄區及自動旋轉限制，autoplayMaxSpins 為 0 代表不限制
+type JurisdictionSettingsRequest struct {
+	Jurisdiction     string `json:"jurisdiction" binding:"omitempty,alpha,max=10" example:"MT"`
+	AutoplayDisabled bool   `json:"autoplayDisabled" example:"false"`
+	AutoplayMaxSpins int    `json:"autoplayMaxSpins" binding:"min=0,max=10000" example:"100"`
+}
+
+type WalletSettingsRequest struct {
+	URL    string `json:"url" binding:"required,url,max=255" example:"https://wallet.acme.example.com"`
+	Secret string `json:"secret" binding:"required,min=16,max=255" example:"9c1f3e7a5b2d4c6e8f0a1b3c5d7e9f1a"`
+}
+
+type GameSettingsRequest struct {
+	Enabled  *bool             `json:"enabled" binding:"required" example:"true"`
+	Branding map[string]string `json:"branding"`
+}
+
+type GameSettingsResponse struct {
+	Success   bool              `json:"success" example:"true"`
+	GameID    string            `json:"gameId" example:"classic"`
+	Enabled   bool              `json:"enabled" example:"true"`
+	Branding  map[string]string `json:"branding"`
+	BetLimits []BetLimitInfo    `json:"betLimits"`
+}
+
+type OperatorsResponse struct {
+	Success   bool              `json:"success" example:"true"`
+	Operators []entity.Operator `json:"operators"`
+}
+
+type OperatorHandler struct {
+	operatorService service.OperatorService
+	currencyService service.CurrencyService
+	config          *config.Config
+}
+
+func NewOperatorHandler(operatorService service.OperatorService, currencyService service.CurrencyService, cfg *config.Config) *OperatorHandler {
+	return &OperatorHandler{
+		operatorService: operatorService,
+		currencyService: currencyService,
+		config:          cfg,
+	}
+}
+
+// CreateSession godoc
+// @Summary      Create game session
+// @Description  Create or map an operator's player, issue a short-lived game session token and return the game launch URL
+// @Tags         operator
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key header string true "Operator API key"
+// @Param        request body CreateSessionRequest true "Session"
+// @Success      200  {object}  SessionResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/operator/sessions [post]
+func (h *OperatorHandler) CreateSession(c *gin.Context) {
+	operator, ok := getOperator(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid operator",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	var req CreateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	gameID := req.GameID
+	if gameID == "" {
+		gameID = domain.DefaultGameID
+	}
+	session, err := h.operatorService.CreateSession(operator, service.SessionRequest{
+		ExternalPlayerID: req.PlayerID,
+		Nickname:         req.Nickname,
+		GameID:           gameID,
+		Currency:         requestCurrency(req.Currency, h.config.Currency.Default),
+		Language:         req.Language,
+		LobbyURL:         req.LobbyURL,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidPlayerID), errors.Is(err, service.ErrCurrencyNotSupported):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrGameNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrGameDisabled), errors.Is(err, service.ErrUserBlocked),
+			errors.Is(err, service.ErrUserNotFound):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SessionResponse{
+		Success:   true,
+		Token:     session.Token,
+		ExpiresAt: session.ExpiresAt,
+		LaunchURL: session.LaunchURL,
+	})
+}
+
+// CreateOperator godoc
+// @Summary      Create operator
+// @Description  Register an operator; the API key is only returned in this response
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request body CreateOperatorRequest true "Operator"
+// @Success      201  {object}  OperatorResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/admin/operators [post]
+func (h *OperatorHandler) CreateOperator(c *gin.Context) {
+	var req CreateOperatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	operator, apiKey, err := h.operatorService.CreateOperator(req.Code, req.Name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrOperatorExists) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, OperatorResponse{
+		Success:  true,
+		Operator: *operator,
+		APIKey:   apiKey,
+	})
+}
+
+// ListOperators godoc
+// @Summary      List operators
+// @Description  List all registered operators
+// @Tags         admin
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  OperatorsResponse
+// @Failure      403  {object}  ErrorResponse
+// @Router       /api/v1/admin/operators [get]
+func (h *OperatorHandler) ListOperators(c *gin.Context) {
+	operators, err := h.operatorService.ListOperators()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to list operators",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, OperatorsResponse{
+		Success:   true,
+		Operators: operators,
+	})
+}
+
+// SetWallet godoc
+// @Summary      Set operator wallet
+// @Description  Set the wallet API URL and HMAC signing secret; once set, the operator's players debit and credit through the operator wallet instead of the internal ledger
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path  int                    true  "Operator ID"
+// @Param        request body  WalletSettingsRequest  true  "Wallet settings"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/operators/{id}/wallet [put]
+func (h *OperatorHandler) SetWallet(c *gin.Context) {
+	operatorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || operatorID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid operator id",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req WalletSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.operatorService.SetWallet(operatorID, req.URL, req.Secret); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrOperatorNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "wallet settings updated"})
+}
+
+// SetJurisdiction godoc
+// @Summary      Set operator jurisdiction
+// @Description  Set the operator's jurisdiction and autoplay rules. Autoplay is unavailable when disabled or in a jurisdiction that bans it (UK, GB); autoplayMaxSpins caps the spins of one autoplay, 0 for no cap
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path  int                          true  "Operator ID"
+// @Param        request body  JurisdictionSettingsRequest  true  "Jurisdiction settings"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/operators/{id}/jurisdiction [put]
+func (h *OperatorHandler) SetJurisdiction(c *gin.Context) {
+	operatorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || operatorID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid operator id",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req JurisdictionSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	err = h.operatorService.SetJurisdiction(operatorID, service.JurisdictionSettings{
+		Jurisdiction:     req.Jurisdiction,
+		AutoplayDisabled: req.AutoplayDisabled,
+		AutoplayMaxSpins: req.AutoplayMaxSpins,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrOperatorNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "jurisdiction settings updated"})
+}
+
+// GetGameSettings godoc
+// @Summary      Get game settings
+// @Description  Get the availability, branding and bet limits of a game for the caller's operator
+// @Tags         game
+// @Produce      json
+// @Security     Bearer
+// @Param        id path string true "Game ID"
+// @Success      200  {object}  GameSettingsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/games/{id}/settings [get]
+func (h *OperatorHandler) GetGameSettings(c *gin.Context) {
+	operatorID, ok := getOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid operator",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	settings, err := h.operatorService.GameSettings(operatorID, c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrGameNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	limits, err := h.currencyService.ListBetLimits(operatorID, settings.GameID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get bet limits",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, GameSettingsResponse{
+		Success:   true,
+		GameID:    settings.GameID,
+		Enabled:   settings.Enabled,
+		Branding:  settings.Branding,
+		BetLimits: newBetLimitInfos(limits),
+	})
+}
+
+// SetGameSettings godoc
+// @Summary      Set operator game settings
+// @Description  Enable or disable a game for one operator and set its branding
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path  int                  true  "Operator ID"
+// @Param        gameId  path  string               true  "Game ID"
+// @Param        request body  GameSettingsRequest  true  "Game settings"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Router       /api/v1/admin/operators/{id}/games/{gameId} [put]
+func (h *OperatorHandler) SetGameSettings(c *gin.Context) {
+	operatorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || operatorID <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid operator id",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req GameSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	err = h.operatorService.SetGameSettings(service.GameSettings{
+		OperatorID: operatorID,
+		GameID:     c.Param("gameId"),
+		Enabled:    *req.Enabled,
+		Branding:   req.Branding,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrGameNotFound) || errors.Is(err, service.ErrOperatorNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "game settings updated"})
+}
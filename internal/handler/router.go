@@ -2,7 +2,10 @@ package handler
 
 import (
 	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/entity"
 	"passontw-slot-game/internal/middleware"
+	"passontw-slot-game/internal/pkg/token"
+	"passontw-slot-game/internal/service"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -15,32 +18,140 @@ func NewRouter(
 	gameHandler *GameHandler,
 	authHandler *AuthHandler,
 	userHandler *UserHandler,
+	autoplayHandler *AutoplayHandler,
+	gameConfigHandler *GameConfigHandler,
+	walletHandler *WalletHandler,
+	currencyHandler *CurrencyHandler,
+	operatorHandler *OperatorHandler,
+	adminHandler *AdminHandler,
+	twoFactorHandler *TwoFactorHandler,
+	operatorService service.OperatorService,
+	tokenService service.TokenService,
 	wsHandler *WebSocketHandler,
-) *gin.Engine {
+) (*gin.Engine, error) {
 	router := gin.Default()
 
+	// 未設定可信任的代理時不採用 X-Forwarded-For，ClientIP 為連線的來源位址，登入限制及稽核紀錄不會被偽造的 header 影響
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+
 	router.Use(middleware.Logger())
 
 	router.GET("/api-docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	router.GET("/hello", helloHandler.HelloWorld)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 	router.GET("/ws", wsHandler.HandleWebSocket)
 
 	// API 路由組
 	v1 := router.Group("/api/v1")
 	{
 		v1.POST("/auth", authHandler.userLogin)
+		v1.POST("/auth/refresh", authHandler.Refresh)
+		v1.POST("/auth/register/otp", authHandler.RequestRegistrationOTP)
+		v1.POST("/auth/register/verify", authHandler.VerifyRegistrationOTP)
+		v1.POST("/auth/register", authHandler.Register)
+		v1.POST("/auth/password/forgot", authHandler.ForgotPassword)
+		v1.POST("/auth/password/verify", authHandler.VerifyPasswordResetOTP)
+		v1.POST("/auth/password/reset", authHandler.ResetPassword)
+		v1.POST("/auth/2fa/verify", twoFactorHandler.Verify)
+
+		// 尚未設定兩步驟驗證的管理者以登入時取得的設定用 token 完成設定
+		enrollment := v1.Group("/auth/2fa")
+		enrollment.Use(middleware.AuthOrChallenge(tokenService, token.ScopeTwoFactorEnroll))
+		{
+			enrollment.POST("/enroll", twoFactorHandler.Enroll)
+			enrollment.POST("/confirm", twoFactorHandler.Confirm)
+		}
+		v1.GET("/games/:id/symbols", gameHandler.GetSymbols)
+		v1.GET("/games/:id/bet-limits", currencyHandler.GetBetLimits)
 
 		authorized := v1.Group("")
-		authorized.Use(middleware.AuthMiddleware(cfg))
+		authorized.Use(middleware.AuthMiddleware(tokenService))
 		{
-			authorized.GET("/users", userHandler.GetUsers)
-			authorized.POST("/users", userHandler.CreateUser)
-			router.POST("/game/spin", gameHandler.GetGameSpin)
+			authorized.POST("/auth/logout", authHandler.Logout)
+			authorized.PUT("/auth/password", authHandler.ChangePassword)
+			authorized.DELETE("/auth/2fa", twoFactorHandler.Disable)
+			authorized.GET("/users", middleware.RequirePermission(entity.PermUsersRead), userHandler.GetUsers)
+			authorized.POST("/users", middleware.RequirePermission(entity.PermUsersWrite), userHandler.CreateUser)
+			authorized.GET("/users/me", userHandler.GetMe)
+			authorized.PATCH("/users/me", userHandler.UpdateMe)
+			authorized.DELETE("/users/me", userHandler.DeleteMe)
+			authorized.GET("/users/:id", middleware.RequirePermission(entity.PermUsersRead), userHandler.GetUser)
+			authorized.PATCH("/users/:id", middleware.RequirePermission(entity.PermUsersWrite), userHandler.UpdateUser)
+			authorized.DELETE("/users/:id", middleware.RequirePermission(entity.PermUsersWrite), userHandler.DeleteUser)
+			authorized.POST("/game/spin", gameHandler.GetGameSpin)
+			authorized.GET("/game/rounds/open", gameHandler.GetOpenRounds)
+			authorized.GET("/game/rounds/:id/replay", gameHandler.ReplayRound)
+			authorized.POST("/game/rounds/:id/spin", gameHandler.PlayRound)
+			authorized.POST("/game/rounds/:id/hold", gameHandler.HoldReels)
+			authorized.POST("/game/rounds/:id/nudge", gameHandler.NudgeReels)
+			authorized.POST("/game/autoplay", autoplayHandler.StartAutoplay)
+			authorized.DELETE("/game/autoplay", autoplayHandler.CancelAutoplay)
+			authorized.GET("/wallets", walletHandler.GetWallets)
+			authorized.GET("/games/:id/settings", operatorHandler.GetGameSettings)
 		}
+
+		operator := v1.Group("/operator")
+		operator.Use(middleware.OperatorAuth(operatorService))
+		{
+			operator.POST("/sessions", operatorHandler.CreateSession)
+		}
+
+		// 管理介面只處理管理者所屬營運商的資料，各路由依權限開放
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(tokenService))
+		{
+			registerPlayerRoutes(admin, ":id", ":id", adminHandler, walletHandler, gameHandler)
+			admin.GET("/exchange-rates", middleware.RequirePermission(entity.PermReportsRead), currencyHandler.GetExchangeRates)
+			admin.GET("/reports/summary", middleware.RequirePermission(entity.PermReportsRead), currencyHandler.GetSummaryReport)
+		}
+
+		// 遊戲設定、匯率及營運商管理影響所有營運商，只開放給平台的使用者
+		platform := admin.Group("")
+		platform.Use(middleware.RequireOperator(operatorService.PlatformOperatorID()))
+		{
+			platform.POST("/games/:id/drafts", middleware.RequirePermission(entity.PermGamesConfig), gameConfigHandler.CreateDraft)
+			platform.GET("/games/:id/drafts", middleware.RequirePermission(entity.PermGamesConfig), gameConfigHandler.ListDrafts)
+			platform.GET("/games/:id/drafts/:draftId", middleware.RequirePermission(entity.PermGamesConfig), gameConfigHandler.GetDraft)
+			platform.POST("/games/:id/drafts/:draftId/validate", middleware.RequirePermission(entity.PermGamesConfig), gameConfigHandler.ValidateDraft)
+			platform.POST("/games/:id/drafts/:draftId/publish", middleware.RequirePermission(entity.PermGamesConfig), gameConfigHandler.PublishDraft)
+			platform.GET("/games/:id/revisions", middleware.RequirePermission(entity.PermGamesConfig), gameConfigHandler.ListRevisions)
+			platform.POST("/games/:id/rollback", middleware.RequirePermission(entity.PermGamesConfig), gameConfigHandler.Rollback)
+			platform.PUT("/games/:id/bet-limits/:currency", middleware.RequirePermission(entity.PermGamesConfig), currencyHandler.SetBetLimits)
+			platform.PUT("/exchange-rates/:currency", middleware.RequirePermission(entity.PermRatesWrite), currencyHandler.SetExchangeRate)
+			platform.GET("/operators", middleware.RequirePermission(entity.PermOperatorsManage), operatorHandler.ListOperators)
+			platform.POST("/operators", middleware.RequirePermission(entity.PermOperatorsManage), operatorHandler.CreateOperator)
+			platform.PUT("/operators/:id/wallet", middleware.RequirePermission(entity.PermOperatorsManage), operatorHandler.SetWallet)
+			platform.PUT("/operators/:id/jurisdiction", middleware.RequirePermission(entity.PermOperatorsManage), operatorHandler.SetJurisdiction)
+			platform.PUT("/operators/:id/games/:gameId", middleware.RequirePermission(entity.PermOperatorsManage), operatorHandler.SetGameSettings)
+			platform.PUT("/operators/:id/games/:gameId/bet-limits/:currency", middleware.RequirePermission(entity.PermOperatorsManage), currencyHandler.SetOperatorBetLimits)
+			platform.GET("/operators/:id/reports/summary", middleware.RequirePermission(entity.PermReportsRead), currencyHandler.GetOperatorSummaryReport)
+		}
+
+		// 平台管理者以 /admin/operators/:id 管理其他營運商的玩家，使用者及局號改以 :userId 及 :roundId 傳入
+		scoped := platform.Group("/operators/:id")
+		scoped.Use(middleware.ScopeOperator())
+		registerPlayerRoutes(scoped, ":userId", ":roundId", adminHandler, walletHandler, gameHandler)
 	}
 
-	return router
+	return router, nil
+}
+
+// registerPlayerRoutes 註冊玩家管理路由，userParam 及 roundParam 為路徑中使用者及局號的參數名稱
+func registerPlayerRoutes(group *gin.RouterGroup, userParam, roundParam string, adminHandler *AdminHandler, walletHandler *WalletHandler, gameHandler *GameHandler) {
+	user := "/users/" + userParam
+	group.GET("/users", middleware.RequirePermission(entity.PermUsersRead), adminHandler.SearchPlayers)
+	group.GET(user, middleware.RequirePermission(entity.PermUsersRead), adminHandler.GetPlayer)
+	group.PUT(user+"/role", middleware.RequirePermission(entity.PermUsersRoles), adminHandler.SetRole)
+	group.POST(user+"/block", middleware.RequirePermission(entity.PermUsersBlock), adminHandler.BlockPlayer)
+	group.POST(user+"/unblock", middleware.RequirePermission(entity.PermUsersBlock), adminHandler.UnblockPlayer)
+	group.POST(user+"/restore", middleware.RequirePermission(entity.PermUsersWrite), adminHandler.RestorePlayer)
+	group.POST(user+"/wallets/deposit", middleware.RequirePermission(entity.PermWalletsAdjust), walletHandler.Deposit)
+	group.POST(user+"/wallets/adjust", middleware.RequirePermission(entity.PermWalletsAdjust), adminHandler.AdjustBalance)
+	group.POST(user+"/rounds/close", middleware.RequirePermission(entity.PermRoundsClose), adminHandler.CloseRounds)
+	group.GET("/rounds/"+roundParam+"/replay", middleware.RequirePermission(entity.PermRoundsRead), gameHandler.AdminReplayRound)
 }
 
 func StartServer(router *gin.Engine, cfg *config.Config) {
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/token"
+	"passontw-slot-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+	userService      service.UserService
+	tokenService     service.TokenService
+	loginGuard       service.LoginGuard
+}
+
+func NewTwoFactorHandler(
+	twoFactorService service.TwoFactorService,
+	userService service.UserService,
+	tokenService service.TokenService,
+	loginGuard service.LoginGuard,
+) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userService:      userService,
+		tokenService:     tokenService,
+		loginGuard:       loginGuard,
+	}
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=20" example:"123456"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required,max=20" example:"123456"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauthUri" example:"otpauth://totp/Passontw%20Slot%20Game:0987654321?algorithm=SHA1&digits=6&issuer=Passontw+Slot+Game&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+type TwoFactorConfirmResponse struct {
+	LoginResponse
+	RecoveryCodes []string `json:"recoveryCodes" example:"abcde-fghij"`
+}
+
+// Enroll godoc
+// @Summary      Start two-factor enrolment
+// @Description  Generate a TOTP secret and its otpauth URI for an authenticator app. Two-factor authentication is enabled only after confirming a code. Accepts an access token or the enrolment token returned by login
+// @Tags         auth
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  TwoFactorEnrollResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/auth/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	claims, userID, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(claims.OperatorID, userID)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorEnrollResponse{
+		Secret:     enrollment.Secret,
+		OtpauthURI: enrollment.URI,
+	})
+}
+
+// Confirm godoc
+// @Summary      Confirm two-factor enrolment
+// @Description  Enable two-factor authentication with a code from the authenticator app. Returns recovery codes, shown only once, and new tokens; every other session must log in again
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request body TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success      200  {object}  TwoFactorConfirmResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/auth/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	claims, userID, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(claims.OperatorID, userID, req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+
+	pair, err := h.reissue(claims, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorConfirmResponse{
+		LoginResponse: newLoginResponse(pair),
+		RecoveryCodes: codes,
+	})
+}
+
+// Verify godoc
+// @Summary      Complete two-factor login
+// @Description  Exchange the challenge token returned by login and a code from the authenticator app, or an unused recovery code, for access and refresh tokens
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body TwoFactorVerifyRequest true "Challenge token and code"
+// @Success      200  {object}  LoginResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
+// @Router       /api/v1/auth/2fa/verify [post]
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	var req TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := h.tokenService.ValidateChallenge(req.ChallengeToken, token.ScopeTwoFactor)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid challenge token"})
+		return
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid challenge token"})
+		return
+	}
+	user, err := h.userService.GetUser(claims.OperatorID, userID)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	if user.Status == entity.UserStatusBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": service.ErrUserBlocked.Error()})
+		return
+	}
+
+	// 錯誤的驗證碼與錯誤的密碼一同計入登入失敗次數
+	ip := c.ClientIP()
+	if err := h.loginGuard.Check(user.OperatorID, user.Phone, ip); err != nil {
+		otpError(c, err)
+		return
+	}
+	err = h.twoFactorService.Verify(user.OperatorID, user.ID, req.Code)
+	if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		if err := h.loginGuard.Fail(user.OperatorID, user.Phone, ip); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	if err := h.loginGuard.Succeed(user.OperatorID, user.Phone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.reissue(claims, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := newLoginResponse(pair)
+	response.User = user
+	c.JSON(http.StatusOK, response)
+}
+
+// Disable godoc
+// @Summary      Disable two-factor authentication
+// @Description  Turn off two-factor authentication after checking a code or recovery code. Accounts with admin permissions cannot disable it
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request body TwoFactorCodeRequest true "Code from the authenticator app or a recovery code"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /api/v1/auth/2fa [delete]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	claims, userID, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(claims.OperatorID, userID, req.Code); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// reissue 撤銷目前使用的 token 並發出新的 access token 及 refresh token
+func (h *TwoFactorHandler) reissue(claims *token.Claims, userID int) (*service.TokenPair, error) {
+	if err := h.tokenService.Revoke(claims, ""); err != nil {
+		return nil, err
+	}
+	user, err := h.userService.GetUser(claims.OperatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return h.tokenService.Issue(user)
+}
+
+// tokenUser 從 context 取得 token claims 及使用者 ID，失敗時直接回應
+func tokenUser(c *gin.Context) (*token.Claims, int, bool) {
+	claims, ok := getTokenClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, 0, false
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return nil, 0, false
+	}
+	return claims, userID, true
+}
+
+// twoFactorError 將兩步驟驗證相關的錯誤轉換為 HTTP 回應
+func twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorEnabled),
+		errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
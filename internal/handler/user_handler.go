@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"passontw-slot-game/internal/domain/entity"
 	"passontw-slot-game/internal/service"
 
 	"strconv"
@@ -10,12 +12,14 @@ import (
 )
 
 type UserHandler struct {
-	userService service.UserService
+	userService  service.UserService
+	auditService service.AuditService
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, auditService service.AuditService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:  userService,
+		auditService: auditService,
 	}
 }
 
@@ -25,6 +29,17 @@ type CreateUserRequest struct {
 	Password string `json:"password" binding:"required,min=6,max=50" example:"a12345678"`
 }
 
+// UpdateUserRequest 只更新有提供的欄位
+type UpdateUserRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=20" example:"testdemo001"`
+	Phone *string `json:"phone" binding:"omitempty,min=1,max=20" example:"0987654321"`
+}
+
+// UpdateMeRequest 使用者只能修改自己的名稱，電話是登入及重設密碼使用的帳號
+type UpdateMeRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1,max=20" example:"testdemo001"`
+}
+
 type PaginatedResponse struct {
 	Data       interface{} `json:"data"`
 	Total      int64       `json:"total" example:"200"`
@@ -45,6 +60,12 @@ type PaginatedResponse struct {
 // @Success      200  {object}  PaginatedResponse
 // @Router       /api/v1/users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
+	operatorID, ok := getOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid operator"})
+		return
+	}
+
 	// 獲取分頁參數
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page < 1 {
@@ -62,7 +83,7 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 	}
 
 	// 獲取用戶列表
-	users, total, err := h.userService.GetUsers(page, pageSize)
+	users, total, err := h.userService.GetUsers(operatorID, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get users"})
 		return
@@ -89,15 +110,26 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 // @Param        request body     CreateUserRequest true "Create User Request"
 // @Success      201  {object}  entity.User
 // @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
 // @Router       /api/v1/users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
+	operatorID, ok := getOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid operator"})
+		return
+	}
+
 	var req CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.userService.CreateUser(req.Name, req.Phone, req.Password)
+	user, err := h.userService.CreateUser(operatorID, req.Name, req.Phone, req.Password)
+	if errors.Is(err, service.ErrPhoneRegistered) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -105,3 +137,221 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, user)
 }
+
+// GetUser godoc
+// @Summary      Get user
+// @Description  Get a user of the caller's operator
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "User ID"
+// @Success      200  {object}  entity.User
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	operatorID, userID, ok := userParams(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(operatorID, userID)
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUser godoc
+// @Summary      Update user
+// @Description  Update the name or phone of a user of the caller's operator; omitted fields are unchanged. Changing the phone revokes the user's sessions and is audited
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id      path  int                true  "User ID"
+// @Param        request body  UpdateUserRequest  true  "Fields to update"
+// @Success      200  {object}  entity.User
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /api/v1/users/{id} [patch]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	operatorID, userID, ok := userParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 記錄變更前的電話，電話變更時寫入稽核紀錄
+	var previousPhone string
+	if req.Phone != nil {
+		previous, err := h.userService.GetUser(operatorID, userID)
+		if err != nil {
+			userError(c, err)
+			return
+		}
+		previousPhone = previous.Phone
+	}
+
+	user, err := h.userService.UpdateUser(operatorID, userID, service.UserUpdate{
+		Name:  req.Name,
+		Phone: req.Phone,
+	})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	if req.Phone != nil && user.Phone != previousPhone {
+		recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditUserPhone, map[string]interface{}{
+			"previousPhone": previousPhone,
+			"phone":         user.Phone,
+		})
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser godoc
+// @Summary      Delete user
+// @Description  Soft-delete a user of the caller's operator. The user can no longer log in and its sessions are revoked; an admin can restore it
+// @Tags         users
+// @Security     Bearer
+// @Param        id path int true "User ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /api/v1/users/{id} [delete]
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	operatorID, userID, ok := userParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(operatorID, userID); err != nil {
+		userError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetMe godoc
+// @Summary      Get current user
+// @Description  Get the profile of the logged-in user
+// @Tags         users
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  entity.User
+// @Failure      401  {object}  map[string]string
+// @Router       /api/v1/users/me [get]
+func (h *UserHandler) GetMe(c *gin.Context) {
+	operatorID, userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(operatorID, userID)
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe godoc
+// @Summary      Update current user
+// @Description  Update the name of the logged-in user
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        request body UpdateMeRequest true "Fields to update"
+// @Success      200  {object}  entity.User
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Router       /api/v1/users/me [patch]
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	operatorID, userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateMeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateUser(operatorID, userID, service.UserUpdate{Name: req.Name})
+	if err != nil {
+		userError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteMe godoc
+// @Summary      Delete current user
+// @Description  Soft-delete the logged-in user's account and revoke its sessions
+// @Tags         users
+// @Security     Bearer
+// @Success      204
+// @Failure      401  {object}  map[string]string
+// @Router       /api/v1/users/me [delete]
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	operatorID, userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(operatorID, userID); err != nil {
+		userError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// userParams 取得呼叫者所屬的營運商及路徑中的使用者 ID，失敗時直接回應
+func userParams(c *gin.Context) (int, int, bool) {
+	operatorID, ok := getOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid operator"})
+		return 0, 0, false
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	return operatorID, userID, true
+}
+
+// currentUser 取得登入使用者的營運商及 ID，失敗時直接回應
+func currentUser(c *gin.Context) (int, int, bool) {
+	operatorID, ok := getOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid operator"})
+		return 0, 0, false
+	}
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+		return 0, 0, false
+	}
+	return operatorID, userID, true
+}
+
+func userError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPhoneRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/service"
+	"passontw-slot-game/pkg/money"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BalanceInfo struct {
+	Currency string `json:"currency" example:"TWD"`
+	Balance  string `json:"balance" example:"1000.00"`
+}
+
+type WalletsResponse struct {
+	Success bool          `json:"success" example:"true"`
+	Wallets []BalanceInfo `json:"wallets"`
+}
+
+type DepositRequest struct {
+	Currency string `json:"currency" binding:"required,alpha,max=10" example:"TWD"`
+	Amount   string `json:"amount" binding:"required" example:"1000.00"`
+	Reason   string `json:"reason" binding:"required,max=255" example:"welcome bonus"`
+}
+
+type WalletHandler struct {
+	walletService service.WalletService
+	auditService  service.AuditService
+}
+
+func NewWalletHandler(walletService service.WalletService, auditService service.AuditService) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+		auditService:  auditService,
+	}
+}
+
+// GetWallets godoc
+// @Summary      Get wallet balances
+// @Description  Get the current user's balance in every currency
+// @Tags         wallet
+// @Produce      json
+// @Security     Bearer
+// @Success      200  {object}  WalletsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Router       /api/v1/wallets [get]
+func (h *WalletHandler) GetWallets(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid user",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	balances, err := h.walletService.Balances(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to get wallets",
+			Code:  http.StatusInternalServerError,
+		})
+		return
+	}
+
+	wallets := make([]BalanceInfo, 0, len(balances))
+	for _, balance := range balances {
+		wallets = append(wallets, newBalanceInfo(balance))
+	}
+	c.JSON(http.StatusOK, WalletsResponse{
+		Success: true,
+		Wallets: wallets,
+	})
+}
+
+// Deposit godoc
+// @Summary      Deposit to player wallet
+// @Description  Credit the wallet of a player of the caller's operator in the given currency; the reason and the acting admin are recorded in the wallet ledger and audit log
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "User ID"
+// @Param        request body DepositRequest true "Deposit"
+// @Success      200  {object}  BalanceInfo
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Router       /api/v1/admin/users/{id}/wallets/deposit [post]
+// @Router       /api/v1/admin/operators/{id}/users/{userId}/wallets/deposit [post]
+func (h *WalletHandler) Deposit(c *gin.Context) {
+	operatorID, ok := getTargetOperatorID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "invalid operator",
+			Code:  http.StatusUnauthorized,
+		})
+		return
+	}
+
+	userID, err := strconv.Atoi(resourceParam(c, "userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "invalid user id",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req DepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid request parameters",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	amount, err := money.Parse(req.Amount, requestCurrency(req.Currency, ""))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Invalid amount",
+			Code:  http.StatusBadRequest,
+		})
+		return
+	}
+
+	actorID, _ := getUserID(c)
+	balance, err := h.walletService.Deposit(operatorID, userID, actorID, amount, req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidAmount):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrUserNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrOperatorManaged):
+			status = http.StatusConflict
+		}
+		c.JSON(status, ErrorResponse{
+			Error: err.Error(),
+			Code:  status,
+		})
+		return
+	}
+	recordAdminAction(c, h.auditService, operatorID, userID, entity.AuditWalletDeposit, map[string]interface{}{
+		"currency": string(amount.Currency()),
+		"amount":   amount.String(),
+		"balance":  balance.String(),
+		"reason":   req.Reason,
+	})
+
+	c.JSON(http.StatusOK, newBalanceInfo(balance))
+}
+
+func newBalanceInfo(balance money.Money) BalanceInfo {
+	return BalanceInfo{
+		Currency: string(balance.Currency()),
+		Balance:  balance.String(),
+	}
+}
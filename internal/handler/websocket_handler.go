@@ -2,14 +2,12 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"passontw-slot-game/internal/service"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 )
 
@@ -42,21 +40,28 @@ type Message struct {
 	Content interface{} `json:"content"`
 }
 
+type directMessage struct {
+	userID  string
+	message []byte
+}
+
 type WebSocketHandler struct {
-	clients     map[*Client]bool
-	broadcast   chan []byte
-	register    chan *Client
-	unregister  chan *Client
-	authService service.AuthService
+	clients      map[*Client]bool
+	broadcast    chan []byte
+	direct       chan directMessage
+	register     chan *Client
+	unregister   chan *Client
+	tokenService service.TokenService
 }
 
-func NewWebSocketHandler(authService service.AuthService) *WebSocketHandler {
+func NewWebSocketHandler(tokenService service.TokenService) *WebSocketHandler {
 	h := &WebSocketHandler{
-		clients:     make(map[*Client]bool),
-		broadcast:   make(chan []byte),
-		register:    make(chan *Client),
-		unregister:  make(chan *Client),
-		authService: authService,
+		clients:      make(map[*Client]bool),
+		broadcast:    make(chan []byte),
+		direct:       make(chan directMessage),
+		register:     make(chan *Client),
+		unregister:   make(chan *Client),
+		tokenService: tokenService,
 	}
 	// 啟動廣播處理
 	go h.run()
@@ -70,29 +75,21 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	parsedToken, err := h.authService.ValidateToken(token)
-	log.Printf("Token validation result: %v", parsedToken)
+	claims, err := h.tokenService.ValidateAccessToken(token)
 	if err != nil {
 		log.Printf("Token validation error: %v", err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
 	}
 
-	claims, ok := parsedToken.Claims.(jwt.MapClaims)
-	if !ok || !parsedToken.Valid {
-		log.Printf("Invalid claims or token")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-		return
-	}
-
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
 		return
 	}
 
-	userID := fmt.Sprintf("%v", claims["sub"])
-	userName := fmt.Sprintf("%v", claims["name"])
+	userID := claims.Subject
+	userName := claims.Name
 	log.Printf("User connected - ID: %s, Name: %s", userID, userName)
 
 	client := &Client{
@@ -194,6 +191,16 @@ func (c *Client) writePump() {
 	}
 }
 
+// SendToUser 推送消息給指定使用者的所有連線
+func (h *WebSocketHandler) SendToUser(userID string, message Message) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Failed to marshal message for user %s: %v", userID, err)
+		return
+	}
+	h.direct <- directMessage{userID: userID, message: messageBytes}
+}
+
 func (h *WebSocketHandler) unregisterClient(client *Client) {
 	h.unregister <- client
 }
@@ -212,6 +219,19 @@ func (h *WebSocketHandler) run() {
 				log.Printf("Client unregistered: %s", client.userName)
 			}
 
+		case msg := <-h.direct:
+			for client := range h.clients {
+				if client.userID != msg.userID {
+					continue
+				}
+				select {
+				case client.send <- msg.message:
+				default:
+					close(client.send)
+					delete(h.clients, client)
+				}
+			}
+
 		case message := <-h.broadcast:
 			log.Printf("Broadcasting message to %d clients", len(h.clients))
 			for client := range h.clients {
@@ -1,60 +1,135 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
-	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/pkg/token"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// TokenValidator 驗證 access token 並返回其中的 claims
+type TokenValidator interface {
+	ValidateAccessToken(tokenString string) (*token.Claims, error)
+}
+
+// ChallengeValidator 另外驗證兩步驟驗證用的 token
+type ChallengeValidator interface {
+	TokenValidator
+	ValidateChallenge(tokenString, scope string) (*token.Claims, error)
+}
+
+func AuthMiddleware(validator TokenValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 從 header 獲取 Authorization
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
-			c.Abort()
+		tokenString, ok := bearerToken(c)
+		if !ok {
 			return
 		}
 
-		// 檢查 Bearer token 格式
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
+		// 驗證簽章、有效期限及撤銷狀態，使用者所屬的營運商由 token 決定
+		claims, err := validator.ValidateAccessToken(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
+		setClaims(c, claims)
+		c.Next()
+	}
+}
 
-		// 解析和驗證 JWT token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// 驗證簽名算法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(cfg.JWT.Secret), nil
-		})
+// AuthOrChallenge 接受 access token 或指定 scope 的兩步驟驗證 token
+// 用於必須先設定兩步驟驗證才能取得 access token 的使用者
+func AuthOrChallenge(validator ChallengeValidator, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			return
+		}
 
+		claims, err := validator.ValidateAccessToken(tokenString)
+		if err != nil {
+			claims, err = validator.ValidateChallenge(tokenString, scope)
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
-		// 驗證 token 是否有效
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// 將使用者資訊存儲到 context
-			c.Set("userId", claims["sub"])
-			c.Set("userName", claims["name"])
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
-			return
+		setClaims(c, claims)
+		c.Next()
+	}
+}
+
+// bearerToken 讀取 Authorization header 中的 Bearer token，格式錯誤時直接回應
+func bearerToken(c *gin.Context) (string, bool) {
+	// 從 header 獲取 Authorization
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		c.Abort()
+		return "", false
+	}
+
+	// 檢查 Bearer token 格式
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
+		c.Abort()
+		return "", false
+	}
+	return parts[1], true
+}
+
+// setClaims 將使用者資訊存儲到 context
+func setClaims(c *gin.Context, claims *token.Claims) {
+	c.Set("tokenClaims", claims)
+	c.Set("userId", claims.Subject)
+	c.Set("operatorId", claims.OperatorID)
+	c.Set("userName", claims.Name)
+	c.Set("userRole", claims.Role)
+	c.Set("userPermissions", claims.Permissions)
+}
+
+// RequireRole 限制只有指定角色的使用者可以存取，需在 AuthMiddleware 之後使用
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("userRole")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		c.Abort()
+	}
+}
+
+// RequirePermission 限制只有擁有全部指定權限的使用者可以存取，需在 AuthMiddleware 之後使用
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("userPermissions")
+		granted, _ := value.([]string)
+		for _, required := range permissions {
+			if !hasPermission(granted, required) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func hasPermission(granted []string, permission string) bool {
+	for _, p := range granted {
+		if p == permission {
+			return true
 		}
 	}
+	return false
 }
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"passontw-slot-game/internal/domain/entity"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader 營運商 API 金鑰的 header
+const APIKeyHeader = "X-API-Key"
+
+// OperatorAuthenticator 以 API 金鑰查詢營運商
+type OperatorAuthenticator interface {
+	Authenticate(apiKey string) (*entity.Operator, error)
+}
+
+// OperatorAuth 驗證營運商 API 金鑰，並將營運商存入 context
+func OperatorAuth(authenticator OperatorAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operator, err := authenticator.Authenticate(c.GetHeader(APIKeyHeader))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+			c.Abort()
+			return
+		}
+
+		c.Set("operator", operator)
+		c.Set("operatorId", operator.ID)
+		c.Next()
+	}
+}
+
+// RequireOperator 限制只有指定營運商的使用者可以存取，需在 AuthMiddleware 之後使用
+func RequireOperator(operatorID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("operatorId")
+		if id, ok := value.(int); ok && id == operatorID {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		c.Abort()
+	}
+}
+
+// ScopeOperator 以路徑中的 :id 指定管理介面操作的營運商，需在 RequireOperator 之後使用，只開放給平台的使用者
+// 管理者本身所屬的營運商不變，處理器以 targetOperatorId 查詢玩家資料
+func ScopeOperator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operatorID, err := strconv.Atoi(c.Param("id"))
+		if err != nil || operatorID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid operator id"})
+			c.Abort()
+			return
+		}
+
+		c.Set("targetOperatorId", operatorID)
+		c.Next()
+	}
+}
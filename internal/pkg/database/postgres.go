@@ -18,7 +18,9 @@ func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
 		cfg.Database.Name,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true, // 將唯一鍵衝突等資料庫錯誤轉換為 gorm.ErrDuplicatedKey
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -0,0 +1,88 @@
+package seamless
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// 營運商錢包 API 的路徑
+const (
+	PathBalance  = "/balance"
+	PathDebit    = "/debit"
+	PathCredit   = "/credit"
+	PathRollback = "/rollback"
+)
+
+// 簽章相關的 HTTP 標頭
+const (
+	HeaderTimestamp = "X-Timestamp"
+	HeaderSignature = "X-Signature"
+)
+
+// MaxClockSkew 驗證簽章時允許的時間誤差，避免重放攻擊
+const MaxClockSkew = 5 * time.Minute
+
+// 錯誤回應的錯誤碼
+const (
+	CodeInsufficientFunds   = "INSUFFICIENT_FUNDS"
+	CodeTransactionNotFound = "TRANSACTION_NOT_FOUND"
+	CodeInvalidSignature    = "INVALID_SIGNATURE"
+	CodeInvalidRequest      = "INVALID_REQUEST"
+)
+
+// TransactionRequest 扣款、派彩及回滾請求，相同 transactionId 的重複請求必須返回相同結果
+type TransactionRequest struct {
+	TransactionID string `json:"transactionId"`
+	PlayerID      string `json:"playerId"`
+	RoundID       string `json:"roundId"`
+	Currency      string `json:"currency"`
+	Amount        string `json:"amount"` // 十進位字串，例如 "1.50"
+}
+
+// BalanceRequest 查詢餘額請求，currency 為空時返回所有貨幣
+type BalanceRequest struct {
+	PlayerID string `json:"playerId"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// Balance 單一貨幣的餘額
+type Balance struct {
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+// BalanceResponse 營運商返回的餘額，交易請求只返回該貨幣的餘額
+type BalanceResponse struct {
+	Balances []Balance `json:"balances"`
+}
+
+// ErrorResponse 營運商返回的錯誤
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Sign 以 HMAC-SHA256 計算請求簽章，簽章內容為 "時間戳.請求內容"
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 驗證請求簽章及時間戳
+func Verify(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return false
+	}
+	expected := Sign(secret, ts, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
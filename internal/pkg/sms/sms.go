@@ -0,0 +1,34 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+	"passontw-slot-game/internal/config"
+)
+
+// Sender 發送簡訊
+type Sender interface {
+	Send(phone, message string) error
+}
+
+// NewSender 依設定選擇簡訊服務供應商
+func NewSender(cfg *config.Config) (Sender, error) {
+	switch cfg.SMS.Provider {
+	case config.SMSProviderConsole:
+		return NewConsoleSender(), nil
+	default:
+		return nil, fmt.Errorf("unsupported sms provider: %s", cfg.SMS.Provider)
+	}
+}
+
+// consoleSender 將簡訊輸出到 log 而不實際發送，僅供本機開發使用
+type consoleSender struct{}
+
+func NewConsoleSender() Sender {
+	return &consoleSender{}
+}
+
+func (s *consoleSender) Send(phone, message string) error {
+	log.Printf("SMS to %s: %s", phone, message)
+	return nil
+}
@@ -0,0 +1,34 @@
+package token
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrRevoked      = errors.New("token has been revoked")
+)
+
+// 部分驗證 token 的用途，帶有 scope 的 token 不能當作 access token 使用
+const (
+	ScopeTwoFactor       = "2fa"        // 密碼正確，等待輸入兩步驟驗證碼
+	ScopeTwoFactorEnroll = "2fa_enroll" // 密碼正確，必須先設定兩步驟驗證
+)
+
+// Claims access token 的內容，Subject 為使用者 ID，ID (jti) 用於撤銷單一 token
+type Claims struct {
+	Name        string   `json:"name"`
+	Role        string   `json:"role"`
+	OperatorID  int      `json:"op"`
+	Permissions []string `json:"perms"`
+	Scope       string   `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID 返回 Subject 中的使用者 ID
+func (c *Claims) UserID() (int, error) {
+	return strconv.Atoi(c.Subject)
+}
@@ -0,0 +1,267 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnsupportedKey = errors.New("unsupported key type, expected RSA or Ed25519")
+	ErrUnknownKey     = errors.New("unknown signing key")
+)
+
+// JWK 公開金鑰的 JSON Web Key 表示 (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS /.well-known/jwks.json 返回的金鑰集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+	jwk    JWK
+}
+
+// KeySet 簽發 token 使用的私鑰及所有可用於驗證的公鑰
+// 輪替金鑰時以新私鑰簽發，舊金鑰的公鑰保留在驗證清單中，直到以舊金鑰簽發的 token 全部過期
+type KeySet struct {
+	signingKey crypto.Signer
+	signingKID string
+	verify     map[string]verificationKey
+	jwks       JWKS
+}
+
+// LoadKeySet 從 PEM 檔案載入簽發用的私鑰及額外的驗證公鑰
+func LoadKeySet(signingKeyFile string, verifyKeyFiles []string) (*KeySet, error) {
+	data, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signingKeyFile, err)
+	}
+
+	keys, err := NewKeySet(signer)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range verifyKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := parsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if _, err := keys.addVerificationKey(publicKey); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return keys, nil
+}
+
+// GenerateKeySet 產生暫時的 Ed25519 金鑰，重新啟動後先前簽發的 token 全部失效，僅供開發使用
+func GenerateKeySet() (*KeySet, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySet(privateKey)
+}
+
+// NewKeySet 以指定的私鑰建立金鑰集合
+func NewKeySet(signer crypto.Signer) (*KeySet, error) {
+	keys := &KeySet{
+		signingKey: signer,
+		verify:     make(map[string]verificationKey),
+		jwks:       JWKS{Keys: []JWK{}},
+	}
+	kid, err := keys.addVerificationKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	keys.signingKID = kid
+	return keys, nil
+}
+
+// Sign 以目前的私鑰簽發 token，header 中的 kid 標示使用的金鑰
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := k.verify[k.signingKID]
+	t := jwt.NewWithClaims(key.method, claims)
+	t.Header["kid"] = k.signingKID
+	return t.SignedString(k.signingKey)
+}
+
+// Parse 依 header 中的 kid 選擇公鑰驗證 token，簽章演算法必須與金鑰相符
+func (k *KeySet) Parse(tokenString string, claims jwt.Claims, options ...jwt.ParserOption) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := k.verify[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return key.key, nil
+	}, options...)
+	return err
+}
+
+// JWKS 返回所有驗證公鑰
+func (k *KeySet) JWKS() JWKS {
+	return k.jwks
+}
+
+// SigningKeyID 返回目前簽發用金鑰的 kid
+func (k *KeySet) SigningKeyID() string {
+	return k.signingKID
+}
+
+// addVerificationKey 加入驗證公鑰，kid 為金鑰的 RFC 7638 thumbprint
+func (k *KeySet) addVerificationKey(publicKey crypto.PublicKey) (string, error) {
+	var key verificationKey
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		key = verificationKey{
+			method: jwt.SigningMethodRS256,
+			key:    pub,
+			jwk: JWK{
+				KeyType:   "RSA",
+				Algorithm: jwt.SigningMethodRS256.Alg(),
+				N:         encodeSegment(pub.N.Bytes()),
+				E:         encodeSegment(big.NewInt(int64(pub.E)).Bytes()),
+			},
+		}
+	case ed25519.PublicKey:
+		key = verificationKey{
+			method: jwt.SigningMethodEdDSA,
+			key:    pub,
+			jwk: JWK{
+				KeyType:   "OKP",
+				Algorithm: jwt.SigningMethodEdDSA.Alg(),
+				Curve:     "Ed25519",
+				X:         encodeSegment(pub),
+			},
+		}
+	default:
+		return "", ErrUnsupportedKey
+	}
+
+	kid, err := thumbprint(key.jwk)
+	if err != nil {
+		return "", err
+	}
+	if _, exists := k.verify[kid]; exists {
+		return kid, nil
+	}
+	key.jwk.KeyID = kid
+	key.jwk.Use = "sig"
+	k.verify[kid] = key
+	k.jwks.Keys = append(k.jwks.Keys, key.jwk)
+	return kid, nil
+}
+
+// thumbprint 依 RFC 7638 以必要欄位的字典序 JSON 計算 SHA-256
+func thumbprint(jwk JWK) (string, error) {
+	var members interface{}
+	switch jwk.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return encodeSegment(sum[:]), nil
+}
+
+// parsePrivateKey 解析 PKCS#8 或 PKCS#1 格式的 PEM 私鑰
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var key interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// parsePublicKey 解析 PEM 公鑰，也接受私鑰檔案並取出其公鑰
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		signer, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return signer.Public(), nil
+	}
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,109 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附錄 B 的 SHA-1 金鑰 "12345678901234567890" 的 base32 編碼
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfcVectors RFC 6238 附錄 B 的 SHA-1 測試向量，驗證碼取 8 位數結果的後 6 位
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, tt := range rfcVectors {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	got, err := Code(strings.ToLower(rfcSecret), Step(time.Unix(59, 0)))
+	if err != nil || got != "287082" {
+		t.Errorf("Code with lowercase secret = %s, %v, want 287082", got, err)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code with invalid secret expected an error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range rfcVectors {
+		now := time.Unix(tt.unix, 0)
+		step, ok := Validate(rfcSecret, tt.code, now, 0)
+		if !ok || step != Step(now) {
+			t.Errorf("Validate at %d = %d, %v, want %d, true", tt.unix, step, ok, Step(now))
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, err := Code(rfcSecret, Step(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := Code(rfcSecret, Step(now)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tooOld, err := Code(rfcSecret, Step(now)-2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"previous step within skew", previous, 1, Step(now) - 1, true},
+		{"next step within skew", next, 1, Step(now) + 1, true},
+		{"previous step without skew", previous, 0, 0, false},
+		{"outside skew", tooOld, 1, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+		{"too short", "05047", 1, 0, false},
+		{"too long", "0504711", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != secretSize {
+		t.Errorf("GenerateSecret = %q decodes to %d bytes, %v, want %d bytes", secret, len(key), err, secretSize)
+	}
+}
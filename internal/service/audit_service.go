@@ -0,0 +1,59 @@
+package service
+
+import (
+	"encoding/json"
+	"passontw-slot-game/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditEntry 稽核事件，UserID 為 0 代表事件不屬於特定使用者，ActorID 為 0 代表不是管理操作
+type AuditEntry struct {
+	OperatorID int
+	UserID     int
+	ActorID    int
+	Action     string
+	IP         string
+	Detail     map[string]interface{}
+}
+
+// AuditService 寫入安全相關事件及管理操作的稽核紀錄
+type AuditService interface {
+	Record(entry AuditEntry) error
+}
+
+type auditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) AuditService {
+	return &auditService{db: db}
+}
+
+// Record 寫入一筆稽核紀錄
+func (s *auditService) Record(entry AuditEntry) error {
+	detail := entry.Detail
+	if detail == nil {
+		detail = map[string]interface{}{}
+	}
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+
+	record := &entity.AuditLog{
+		CreatedAt:  time.Now(),
+		OperatorID: entry.OperatorID,
+		Action:     entry.Action,
+		IP:         entry.IP,
+		Detail:     string(data),
+	}
+	if entry.UserID != 0 {
+		record.UserID = &entry.UserID
+	}
+	if entry.ActorID != 0 {
+		record.ActorID = &entry.ActorID
+	}
+	return s.db.Create(record).Error
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/pkg/money"
+	"passontw-slot-game/pkg/utils"
+	"sync"
+	"time"
+)
+
+// autoplayInterval 每次自動旋轉之間的間隔
+const autoplayInterval = time.Second
+
+var (
+	ErrAutoplayDisabled     = errors.New("autoplay is disabled in this jurisdiction")
+	ErrAutoplayTooManySpins = errors.New("too many autoplay spins")
+	ErrAutoplayRunning      = errors.New("autoplay already running")
+	ErrAutoplayNotFound     = errors.New("no autoplay running")
+)
+
+// autoplayBannedJurisdictions 禁止自動旋轉的司法管轄區，不論營運商的設定
+var autoplayBannedJurisdictions = map[string]bool{
+	"UK": true,
+	"GB": true,
+}
+
+// AutoplayStopReason 代表自動旋轉停止的原因
+type AutoplayStopReason string
+
+const (
+	AutoplayRunning   AutoplayStopReason = ""
+	AutoplayFinished  AutoplayStopReason = "finished"
+	AutoplayCancelled AutoplayStopReason = "cancelled"
+	AutoplayWinLimit  AutoplayStopReason = "win_limit"
+	AutoplayLossLimit AutoplayStopReason = "loss_limit"
+	AutoplayFeature   AutoplayStopReason = "feature"
+	AutoplayError     AutoplayStopReason = "error"
+)
+
+// AutoplaySettings 自動旋轉的設定及停止條件，0 代表不啟用該條件
+type AutoplaySettings struct {
+	Spins          int
+	BetAmount      money.Money
+	StopOnWinAbove money.Money // 單局獎金超過此金額時停止
+	LossLimit      money.Money // 累計淨虧損達到此金額時停止
+	StopOnFeature  bool        // 觸發特色玩法時停止
+}
+
+// AutoplayUpdate 代表自動旋轉的一次進度通知
+type AutoplayUpdate struct {
+	ID         string
+	Spin       int
+	Result     *SpinResult
+	NetResult  money.Money
+	StopReason AutoplayStopReason
+}
+
+// AutoplayNotifier 接收自動旋轉的進度通知
+type AutoplayNotifier func(userID int, update AutoplayUpdate)
+
+type AutoplayService interface {
+	Start(operatorID, userID int, settings AutoplaySettings, notify AutoplayNotifier) (string, error)
+	Cancel(userID int) error
+}
+
+type autoplaySession struct {
+	id     string
+	cancel context.CancelFunc
+}
+
+type autoplayService struct {
+	gameService GameService
+	currencies  CurrencyService
+	operators   OperatorService
+
+	mu       sync.Mutex
+	sessions map[int]*autoplaySession // 每位使用者同時只能有一個自動旋轉
+}
+
+func NewAutoplayService(gameService GameService, currencies CurrencyService, operators OperatorService) AutoplayService {
+	return &autoplayService{
+		gameService: gameService,
+		currencies:  currencies,
+		operators:   operators,
+		sessions:    make(map[int]*autoplaySession),
+	}
+}
+
+// Start 開始由伺服器驅動的自動旋轉
+func (s *autoplayService) Start(operatorID, userID int, settings AutoplaySettings, notify AutoplayNotifier) (string, error) {
+	if err := s.checkJurisdiction(operatorID, settings.Spins); err != nil {
+		return "", err
+	}
+	if err := s.operators.CheckGame(operatorID, domain.DefaultGameID); err != nil {
+		return "", err
+	}
+	if err := s.currencies.ValidateBet(operatorID, domain.DefaultGameID, settings.BetAmount); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[userID]; ok {
+		return "", ErrAutoplayRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &autoplaySession{
+		id:     utils.NewID(),
+		cancel: cancel,
+	}
+	s.sessions[userID] = session
+
+	go s.run(ctx, operatorID, userID, session, settings, notify)
+
+	return session.id, nil
+}
+
+// checkJurisdiction 依營運商所在的司法管轄區及其自動旋轉限制檢查是否可以開始自動旋轉
+func (s *autoplayService) checkJurisdiction(operatorID, spins int) error {
+	operator, err := s.operators.GetOperator(operatorID)
+	if err != nil {
+		return err
+	}
+	if operator.AutoplayDisabled || autoplayBannedJurisdictions[operator.Jurisdiction] {
+		return ErrAutoplayDisabled
+	}
+	if operator.AutoplayMaxSpins > 0 && spins > operator.AutoplayMaxSpins {
+		return fmt.Errorf("%w: at most %d spins", ErrAutoplayTooManySpins, operator.AutoplayMaxSpins)
+	}
+	return nil
+}
+
+// Cancel 取消使用者進行中的自動旋轉
+func (s *autoplayService) Cancel(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[userID]
+	if !ok {
+		return ErrAutoplayNotFound
+	}
+	session.cancel()
+	return nil
+}
+
+func (s *autoplayService) run(ctx context.Context, operatorID, userID int, session *autoplaySession, settings AutoplaySettings, notify AutoplayNotifier) {
+	defer s.finish(userID, session)
+
+	ticker := time.NewTicker(autoplayInterval)
+	defer ticker.Stop()
+
+	net := money.Zero(settings.BetAmount.Currency())
+	for spin := 1; spin <= settings.Spins; spin++ {
+		select {
+		case <-ctx.Done():
+			notify(userID, AutoplayUpdate{ID: session.id, Spin: spin - 1, NetResult: net, StopReason: AutoplayCancelled})
+			return
+		case <-ticker.C:
+		}
+
+		result, err := s.gameService.Spin(operatorID, userID, settings.BetAmount, "")
+		if err == nil && !settings.StopOnFeature {
+			result, err = s.playFeature(ctx, userID, session.id, spin, net, result, notify)
+		}
+		if err != nil {
+			log.Printf("Autoplay spin error for user %d: %v", userID, err)
+			notify(userID, AutoplayUpdate{ID: session.id, Spin: spin, NetResult: net, StopReason: AutoplayError})
+			return
+		}
+
+		net = net.Add(result.Round.TotalWin).Sub(settings.BetAmount)
+		update := AutoplayUpdate{
+			ID:        session.id,
+			Spin:      spin,
+			Result:    result,
+			NetResult: net,
+		}
+
+		switch {
+		case result.Round.Status == RoundOpen:
+			// 特色玩法尚未完成（設定停止或需要玩家選擇），交由玩家繼續
+			update.StopReason = AutoplayFeature
+		case settings.StopOnWinAbove.IsPositive() && result.Round.TotalWin.Cmp(settings.StopOnWinAbove) > 0:
+			update.StopReason = AutoplayWinLimit
+		case settings.LossLimit.IsPositive() && !net.Add(settings.LossLimit).IsPositive():
+			update.StopReason = AutoplayLossLimit
+		case spin == settings.Spins:
+			update.StopReason = AutoplayFinished
+		}
+
+		notify(userID, update)
+		if update.StopReason != AutoplayRunning {
+			return
+		}
+	}
+}
+
+// playFeature 自動完成免費旋轉及 Hold and Spin，需要玩家選擇的 Hold 或 Nudge 則保留給玩家
+func (s *autoplayService) playFeature(ctx context.Context, userID int, id string, spin int, net money.Money, result *SpinResult, notify AutoplayNotifier) (*SpinResult, error) {
+	for result.Round.Status == RoundOpen && result.Round.Feature != FeatureNudgeHold {
+		if ctx.Err() != nil {
+			return result, nil
+		}
+
+		notify(userID, AutoplayUpdate{ID: id, Spin: spin, Result: result, NetResult: net})
+
+		next, err := s.gameService.PlayRound(userID, result.Round.ID)
+		if err != nil {
+			return nil, err
+		}
+		result = next
+	}
+	return result, nil
+}
+
+func (s *autoplayService) finish(userID int, session *autoplaySession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.cancel()
+	if s.sessions[userID] == session {
+		delete(s.sessions, userID)
+	}
+}
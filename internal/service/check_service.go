@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"passontw-slot-game/internal/domain"
 	"passontw-slot-game/internal/domain/models"
+	"passontw-slot-game/pkg/money"
 )
 
 // WinResult 代表一次遊戲的中獎結果
 type WinResult struct {
-	Lines  []models.WinningLine // 中獎線
-	Payout float64              // 總獎金
+	Lines  []models.WinningLine `json:"lines"`  // 中獎線
+	Payout money.Money          `json:"payout"` // 總獎金
 }
 
 // Checker 負責檢查遊戲規則和計算獎金
@@ -19,72 +20,90 @@ type Checker struct {
 
 // NewChecker 創建新的規則檢查器
 func NewCheckerService() *Checker {
+	return NewCheckerFromDefinition(domain.DefaultGameDefinition())
+}
+
+// NewCheckerFromDefinition 根據遊戲定義創建規則檢查器
+func NewCheckerFromDefinition(definition domain.GameDefinition) *Checker {
 	checker := &Checker{
 		symbolInfo: make(map[domain.Symbol]domain.SymbolInfo),
 	}
 
 	// 初始化符號資訊映射
-	for _, info := range domain.GetSymbolList() {
+	for _, info := range definition.Symbols {
 		checker.symbolInfo[info.Symbol] = info
 	}
 
 	return checker
 }
 
-// CheckWin 檢查盤面是否中獎並計算獎金
-func (c *Checker) CheckWin(board models.Board) WinResult {
-	var result WinResult
+// CheckWin 檢查盤面是否中獎並以下注金額計算獎金
+// 每條中獎線的獎金個別向下捨入至貨幣最小單位後再加總
+func (c *Checker) CheckWin(board models.Board, bet money.Money) WinResult {
+	result := WinResult{Payout: money.Zero(bet.Currency())}
 
 	// 檢查橫向
 	for i := 0; i < 3; i++ {
-		if board[i][0] == board[i][1] && board[i][1] == board[i][2] {
-			line := models.WinningLine{
-				Type:     "Horizontal",
-				Position: i,
-				Symbol:   board[i][0],
-			}
-			result.Lines = append(result.Lines, line)
-			result.Payout += c.symbolInfo[board[i][0]].Payout
+		if symbol, ok := matchLine(board[i][0], board[i][1], board[i][2]); ok {
+			c.addLine(&result, bet, "Horizontal", i, symbol)
 		}
 	}
 
 	// 檢查縱向
 	for j := 0; j < 3; j++ {
-		if board[0][j] == board[1][j] && board[1][j] == board[2][j] {
-			line := models.WinningLine{
-				Type:     "Vertical",
-				Position: j,
-				Symbol:   board[0][j],
-			}
-			result.Lines = append(result.Lines, line)
-			result.Payout += c.symbolInfo[board[0][j]].Payout
+		if symbol, ok := matchLine(board[0][j], board[1][j], board[2][j]); ok {
+			c.addLine(&result, bet, "Vertical", j, symbol)
 		}
 	}
 
 	// 檢查對角線
-	if board[0][0] == board[1][1] && board[1][1] == board[2][2] {
-		line := models.WinningLine{
-			Type:     "Diagonal",
-			Position: 1, // 左上到右下
-			Symbol:   board[1][1],
-		}
-		result.Lines = append(result.Lines, line)
-		result.Payout += c.symbolInfo[board[1][1]].Payout
+	if symbol, ok := matchLine(board[0][0], board[1][1], board[2][2]); ok {
+		c.addLine(&result, bet, "Diagonal", 1, symbol) // 左上到右下
 	}
 
-	if board[0][2] == board[1][1] && board[1][1] == board[2][0] {
-		line := models.WinningLine{
-			Type:     "Diagonal",
-			Position: 2, // 右上到左下
-			Symbol:   board[1][1],
-		}
-		result.Lines = append(result.Lines, line)
-		result.Payout += c.symbolInfo[board[1][1]].Payout
+	if symbol, ok := matchLine(board[0][2], board[1][1], board[2][0]); ok {
+		c.addLine(&result, bet, "Diagonal", 2, symbol) // 右上到左下
 	}
 
 	return result
 }
 
+// addLine 記錄中獎線並累加獎金
+func (c *Checker) addLine(result *WinResult, bet money.Money, lineType string, position int, symbol domain.Symbol) {
+	multiplier := c.symbolInfo[symbol].Payout
+	payout := bet.Mul(multiplier, money.RoundDown)
+	result.Lines = append(result.Lines, models.WinningLine{
+		Type:       lineType,
+		Position:   position,
+		Symbol:     symbol,
+		Multiplier: multiplier,
+		Payout:     payout,
+	})
+	result.Payout = result.Payout.Add(payout)
+}
+
+// matchLine 判斷一條線上的符號是否相同，百搭符號可替代任何符號
+func matchLine(symbols ...domain.Symbol) (domain.Symbol, bool) {
+	target := domain.Wild
+	for _, symbol := range symbols {
+		if symbol == domain.Wild {
+			continue
+		}
+		if target == domain.Wild {
+			target = symbol
+			continue
+		}
+		if symbol != target {
+			return 0, false
+		}
+	}
+	// 金幣只用於 Hold and Spin，不參與連線
+	if target == domain.Coin {
+		return 0, false
+	}
+	return target, true
+}
+
 // FormatWinResult 格式化中獎結果為字符串
 func (c *Checker) FormatWinResult(result WinResult) string {
 	if len(result.Lines) == 0 {
@@ -94,10 +113,10 @@ func (c *Checker) FormatWinResult(result WinResult) string {
 	var output string
 	output = fmt.Sprintf("Found %d winning lines:\n", len(result.Lines))
 	for _, line := range result.Lines {
-		output += fmt.Sprintf("- %s line %d with symbol %s\n",
-			line.Type, line.Position+1, line.Symbol)
+		output += fmt.Sprintf("- %s line %d with symbol %s pays %.2fx\n",
+			line.Type, line.Position+1, line.Symbol, line.Multiplier)
 	}
-	output += fmt.Sprintf("Total payout: %.2fx\n", result.Payout)
+	output += fmt.Sprintf("Total payout: %s %s\n", result.Payout, result.Payout.Currency())
 
 	return output
 }
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/pkg/money"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCurrencyNotSupported = errors.New("currency is not supported by this game")
+	ErrBetNotAllowed        = errors.New("bet amount is not allowed for this currency")
+	ErrInvalidBetLimits     = errors.New("invalid bet limits")
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrInvalidExchangeRate  = errors.New("exchange rate must be a positive decimal")
+)
+
+// SharedBetLimits 所有營運商共用的預設下注限制，營運商可以個別覆寫
+const SharedBetLimits = 0
+
+// BetLimits 遊戲在某貨幣的下注限制，BetLevels 為空時最小與最大下注之間的金額皆可下注
+type BetLimits struct {
+	OperatorID int
+	GameID     string
+	Currency   money.Currency
+	MinBet     money.Money
+	MaxBet     money.Money
+	BetLevels  []money.Money
+}
+
+// defaultBetLimits 遊戲尚未設定任何下注限制時預設開放的貨幣
+var defaultBetLimits = map[money.Currency][]string{
+	"TWD":  {"1", "2", "5", "10", "20", "50", "100", "200", "500", "1000"},
+	"USD":  {"0.1", "0.2", "0.5", "1", "2", "5", "10", "20", "50", "100"},
+	"USDT": {"0.1", "0.2", "0.5", "1", "2", "5", "10", "20", "50", "100"},
+}
+
+type CurrencyService interface {
+	ListBetLimits(operatorID int, gameID string) ([]BetLimits, error)
+	GetBetLimits(operatorID int, gameID string, currency money.Currency) (*BetLimits, error)
+	SetBetLimits(limits BetLimits) error
+	ValidateBet(operatorID int, gameID string, bet money.Money) error
+	ExchangeRates() ([]entity.ExchangeRate, error)
+	SetExchangeRate(currency money.Currency, rate string) error
+	BaseCurrency() money.Currency
+	ToBase(amount money.Money) (money.Money, error)
+	FromBase(amount money.Money, to money.Currency) (money.Money, error)
+}
+
+type currencyService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+func NewCurrencyService(db *gorm.DB, cfg *config.Config) (CurrencyService, error) {
+	s := &currencyService{
+		db:     db,
+		config: cfg,
+	}
+	if err := s.seedBetLimits(domain.DefaultGameID); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// seedBetLimits 遊戲沒有任何下注限制時寫入預設值
+func (s *currencyService) seedBetLimits(gameID string) error {
+	var count int64
+	err := s.db.Model(&entity.GameBetLimit{}).
+		Where("operator_id = ? AND game_id = ?", SharedBetLimits, gameID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	for currency, values := range defaultBetLimits {
+		levels := make([]money.Money, 0, len(values))
+		for _, value := range values {
+			levels = append(levels, money.MustParse(value, currency))
+		}
+		limits := BetLimits{
+			OperatorID: SharedBetLimits,
+			GameID:     gameID,
+			Currency:   currency,
+			MinBet:     levels[0],
+			MaxBet:     levels[len(levels)-1],
+			BetLevels:  levels,
+		}
+		if err := s.SetBetLimits(limits); err != nil {
+			return err
+		}
+	}
+	log.Printf("Seeded default bet limits for game %s", gameID)
+	return nil
+}
+
+// ListBetLimits 返回營運商在遊戲所有貨幣的下注限制，營運商的設定優先於共用預設值
+func (s *currencyService) ListBetLimits(operatorID int, gameID string) ([]BetLimits, error) {
+	var records []entity.GameBetLimit
+	err := s.db.Where("operator_id IN ? AND game_id = ?", []int{SharedBetLimits, operatorID}, gameID).
+		Order("currency, operator_id").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BetLimits, 0, len(records))
+	for _, record := range records {
+		limits, err := decodeBetLimits(record)
+		if err != nil {
+			return nil, err
+		}
+		// 同一貨幣的營運商設定排在共用預設值之後
+		if n := len(result); n > 0 && result[n-1].Currency == limits.Currency {
+			result[n-1] = *limits
+			continue
+		}
+		result = append(result, *limits)
+	}
+	return result, nil
+}
+
+// GetBetLimits 返回營運商在遊戲指定貨幣的下注限制，未開放該貨幣時返回 ErrCurrencyNotSupported
+func (s *currencyService) GetBetLimits(operatorID int, gameID string, currency money.Currency) (*BetLimits, error) {
+	var record entity.GameBetLimit
+	err := s.db.Where("operator_id IN ? AND game_id = ? AND currency = ?", []int{SharedBetLimits, operatorID}, gameID, string(currency)).
+		Order("operator_id DESC").
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCurrencyNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeBetLimits(record)
+}
+
+// SetBetLimits 新增或更新遊戲在某貨幣的下注限制
+func (s *currencyService) SetBetLimits(limits BetLimits) error {
+	if !limits.MinBet.IsPositive() || limits.MaxBet.Cmp(limits.MinBet) < 0 {
+		return ErrInvalidBetLimits
+	}
+
+	levels := make([]int64, 0, len(limits.BetLevels))
+	for i, level := range limits.BetLevels {
+		if level.Cmp(limits.MinBet) < 0 || level.Cmp(limits.MaxBet) > 0 ||
+			(i > 0 && level.Cmp(limits.BetLevels[i-1]) <= 0) {
+			return fmt.Errorf("%w: bet levels must be ascending and within min and max bet", ErrInvalidBetLimits)
+		}
+		levels = append(levels, level.Minor())
+	}
+	data, err := json.Marshal(levels)
+	if err != nil {
+		return err
+	}
+
+	record := &entity.GameBetLimit{
+		OperatorID: limits.OperatorID,
+		UpdatedAt:  time.Now(),
+		GameID:     limits.GameID,
+		Currency:   string(limits.Currency),
+		MinBet:     limits.MinBet.Minor(),
+		MaxBet:     limits.MaxBet.Minor(),
+		BetLevels:  string(data),
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "operator_id"}, {Name: "game_id"}, {Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "min_bet", "max_bet", "bet_levels"}),
+	}).Create(record).Error
+}
+
+// ValidateBet 檢查下注金額是否符合營運商在遊戲該貨幣的下注限制
+func (s *currencyService) ValidateBet(operatorID int, gameID string, bet money.Money) error {
+	limits, err := s.GetBetLimits(operatorID, gameID, bet.Currency())
+	if err != nil {
+		return err
+	}
+
+	if bet.Cmp(limits.MinBet) < 0 || bet.Cmp(limits.MaxBet) > 0 {
+		return ErrBetNotAllowed
+	}
+	if len(limits.BetLevels) == 0 {
+		return nil
+	}
+	for _, level := range limits.BetLevels {
+		if bet.Cmp(level) == 0 {
+			return nil
+		}
+	}
+	return ErrBetNotAllowed
+}
+
+// ExchangeRates 返回所有貨幣對基準貨幣的匯率
+func (s *currencyService) ExchangeRates() ([]entity.ExchangeRate, error) {
+	var rates []entity.ExchangeRate
+	err := s.db.Order("currency").Find(&rates).Error
+	return rates, err
+}
+
+// SetExchangeRate 新增或更新貨幣對基準貨幣的匯率
+func (s *currencyService) SetExchangeRate(currency money.Currency, rate string) error {
+	value, ok := new(big.Rat).SetString(rate)
+	if !ok || value.Sign() <= 0 {
+		return ErrInvalidExchangeRate
+	}
+
+	record := &entity.ExchangeRate{
+		Currency:  string(currency),
+		UpdatedAt: time.Now(),
+		Rate:      rate,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "rate"}),
+	}).Create(record).Error
+}
+
+// BaseCurrency 返回報表使用的基準貨幣
+func (s *currencyService) BaseCurrency() money.Currency {
+	return money.Currency(s.config.Currency.Base)
+}
+
+// ToBase 以匯率表將金額換算為基準貨幣，四捨五入至基準貨幣的最小單位
+func (s *currencyService) ToBase(amount money.Money) (money.Money, error) {
+	base := s.BaseCurrency()
+	if amount.Currency() == base {
+		return amount, nil
+	}
+
+	rate, err := s.exchangeRate(amount.Currency())
+	if err != nil {
+		return money.Money{}, err
+	}
+	return amount.Convert(base, rate.Rate, money.RoundHalfUp)
+}
+
+// FromBase 以匯率表將基準貨幣的金額換算為指定貨幣，無條件捨去至該貨幣的最小單位，用於換算上限金額
+// 以匯率的精確倒數換算，避免倒數的小數誤差使上限超過設定的金額
+func (s *currencyService) FromBase(amount money.Money, to money.Currency) (money.Money, error) {
+	if amount.Currency() == to {
+		return amount, nil
+	}
+
+	rate, err := s.exchangeRate(to)
+	if err != nil {
+		return money.Money{}, err
+	}
+	value, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok || value.Sign() <= 0 {
+		return money.Money{}, fmt.Errorf("%w: %s", ErrInvalidExchangeRate, to)
+	}
+	return amount.Convert(to, new(big.Rat).Inv(value).RatString(), money.RoundDown)
+}
+
+func (s *currencyService) exchangeRate(currency money.Currency) (*entity.ExchangeRate, error) {
+	var rate entity.ExchangeRate
+	err := s.db.Where("currency = ?", string(currency)).First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeRateNotFound, currency)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func decodeBetLimits(record entity.GameBetLimit) (*BetLimits, error) {
+	var levels []int64
+	if err := json.Unmarshal([]byte(record.BetLevels), &levels); err != nil {
+		return nil, err
+	}
+
+	currency := money.Currency(record.Currency)
+	limits := &BetLimits{
+		OperatorID: record.OperatorID,
+		GameID:     record.GameID,
+		Currency:   currency,
+		MinBet:     money.New(record.MinBet, currency),
+		MaxBet:     money.New(record.MaxBet, currency),
+		BetLevels:  make([]money.Money, 0, len(levels)),
+	}
+	for _, level := range levels {
+		limits.BetLevels = append(limits.BetLevels, money.New(level, currency))
+	}
+	return limits, nil
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDraftNotFound     = errors.New("draft not found")
+	ErrDraftPublished    = errors.New("draft already published")
+	ErrDefinitionInvalid = errors.New("game definition is invalid")
+	ErrDraftGameMismatch = errors.New("draft definition belongs to another game")
+)
+
+// ValidationReport 遊戲定義的驗證結果及理論返還率
+type ValidationReport struct {
+	Valid   bool     `json:"valid" example:"true"`
+	Errors  []string `json:"errors"`
+	LineRTP float64  `json:"lineRtp" example:"0.4476"`
+	MinRTP  float64  `json:"minRtp" example:"0.4"`
+	MaxRTP  float64  `json:"maxRtp" example:"0.98"`
+}
+
+// Draft 遊戲定義草稿
+type Draft struct {
+	entity.GameDraft
+	Definition domain.GameDefinition `json:"definition"`
+}
+
+type GameConfigService interface {
+	CreateDraft(gameID string, definition domain.GameDefinition, createdBy int) (*Draft, error)
+	GetDraft(gameID string, draftID int) (*Draft, error)
+	ListDrafts(gameID string) ([]Draft, error)
+	Validate(definition domain.GameDefinition) ValidationReport
+	Publish(gameID string, draftID int) (*Revision, error)
+	Rollback(gameID string) (*Revision, error)
+	History(gameID string) ([]entity.GameRevisionActivation, error)
+}
+
+type gameConfigService struct {
+	db       *gorm.DB
+	paytable PaytableService
+	config   *config.Config
+}
+
+func NewGameConfigService(db *gorm.DB, cfg *config.Config, paytable PaytableService) GameConfigService {
+	return &gameConfigService{
+		db:       db,
+		paytable: paytable,
+		config:   cfg,
+	}
+}
+
+// CreateDraft 上傳新的遊戲定義草稿
+func (s *gameConfigService) CreateDraft(gameID string, definition domain.GameDefinition, createdBy int) (*Draft, error) {
+	if definition.GameID == "" {
+		definition.GameID = gameID
+	}
+	if definition.GameID != gameID {
+		return nil, ErrDraftGameMismatch
+	}
+
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	record := entity.GameDraft{
+		GameID:     gameID,
+		Status:     entity.DraftStatusDraft,
+		Definition: string(data),
+		CreatedBy:  createdBy,
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	return &Draft{GameDraft: record, Definition: definition}, nil
+}
+
+// GetDraft 讀取草稿
+func (s *gameConfigService) GetDraft(gameID string, draftID int) (*Draft, error) {
+	var record entity.GameDraft
+	if err := s.db.Where("id = ? AND game_id = ?", draftID, gameID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDraftNotFound
+		}
+		return nil, err
+	}
+	return decodeDraft(record)
+}
+
+// ListDrafts 列出遊戲的所有草稿，最新的在前
+func (s *gameConfigService) ListDrafts(gameID string) ([]Draft, error) {
+	var records []entity.GameDraft
+	if err := s.db.Where("game_id = ?", gameID).Order("id DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	drafts := make([]Draft, 0, len(records))
+	for _, record := range records {
+		draft, err := decodeDraft(record)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, *draft)
+	}
+	return drafts, nil
+}
+
+// Validate 驗證遊戲定義並計算理論返還率是否在允許範圍內
+func (s *gameConfigService) Validate(definition domain.GameDefinition) ValidationReport {
+	report := ValidationReport{
+		Errors: definition.Validate(),
+		MinRTP: s.config.Game.MinRTP,
+		MaxRTP: s.config.Game.MaxRTP,
+	}
+	if report.Errors == nil {
+		report.Errors = []string{}
+	}
+
+	report.LineRTP = definition.LineRTP()
+	if report.LineRTP < report.MinRTP || report.LineRTP > report.MaxRTP {
+		report.Errors = append(report.Errors, fmt.Sprintf("line RTP %.4f is outside the allowed band %.4f-%.4f",
+			report.LineRTP, report.MinRTP, report.MaxRTP))
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// Publish 驗證草稿後以交易方式登記修訂版、立即上線並標記草稿為已發布
+func (s *gameConfigService) Publish(gameID string, draftID int) (*Revision, error) {
+	var hash string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record entity.GameDraft
+		if err := tx.Where("id = ? AND game_id = ?", draftID, gameID).First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrDraftNotFound
+			}
+			return err
+		}
+		if record.Status == entity.DraftStatusPublished {
+			return ErrDraftPublished
+		}
+
+		draft, err := decodeDraft(record)
+		if err != nil {
+			return err
+		}
+		if !s.Validate(draft.Definition).Valid {
+			return ErrDefinitionInvalid
+		}
+
+		hash, err = s.paytable.Publish(tx, draft.Definition)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&record).Updates(map[string]interface{}{
+			"status":        entity.DraftStatusPublished,
+			"revision_hash": hash,
+			"published_at":  now,
+			"updated_at":    now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 交易提交後才更新快取
+	s.paytable.Invalidate(gameID)
+	return s.paytable.Get(hash)
+}
+
+// Rollback 恢復為上一個修訂版
+func (s *gameConfigService) Rollback(gameID string) (*Revision, error) {
+	return s.paytable.Rollback(gameID)
+}
+
+// History 返回遊戲的修訂版上線紀錄
+func (s *gameConfigService) History(gameID string) ([]entity.GameRevisionActivation, error) {
+	return s.paytable.History(gameID)
+}
+
+func decodeDraft(record entity.GameDraft) (*Draft, error) {
+	var definition domain.GameDefinition
+	if err := json.Unmarshal([]byte(record.Definition), &definition); err != nil {
+		return nil, err
+	}
+	return &Draft{GameDraft: record, Definition: definition}, nil
+}
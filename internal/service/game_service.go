@@ -1,37 +1,107 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"math/rand"
+	"passontw-slot-game/internal/config"
 	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/domain/entity"
 	"passontw-slot-game/internal/domain/models"
+	"passontw-slot-game/pkg/money"
+	"passontw-slot-game/pkg/utils"
+	"sync"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// payoutRetryDelay 派彩失敗後等待多久才由定期結算重試，避免與進行中的請求競爭
+const payoutRetryDelay = time.Minute
+
+var (
+	ErrRoundNotFound  = errors.New("round not found")
+	ErrRoundCompleted = errors.New("round already completed")
+	ErrInvalidAction  = errors.New("invalid action for this round")
+	ErrInvalidBet     = errors.New("bet amount must be positive")
+
+	ErrIdempotencyConflict = errors.New("idempotency key already used with a different request")
 )
 
+// Generator 盤面及輪帶位置的隨機生成器，同一修訂版的生成器由所有遊戲局共用
 type Generator struct {
-	symbols []domain.SymbolInfo
-	rng     *rand.Rand
+	symbols    []domain.SymbolInfo
+	coinValues []domain.CoinValue
+
+	mu  sync.Mutex // 保護隨機數生成器，*rand.Rand 不可同時使用
+	rng *rand.Rand
 }
 
 type GameService interface {
 	GetRamdomSpin() string
 	GenerateBoard() models.Board
 	GenerateBoardWithBias() models.Board
+	Spin(operatorID, userID int, betAmount money.Money, idempotencyKey string) (*SpinResult, error)
+	PlayRound(userID int, roundID string) (*SpinResult, error)
+	Hold(userID int, roundID string, columns []int) (*SpinResult, error)
+	Nudge(userID int, roundID string, columns []int) (*SpinResult, error)
+	OpenRounds(userID int) ([]*SpinResult, error)
+	GetRound(operatorID int, roundID string) (*Round, error)
+	RecentSpins(operatorID, userID, limit int) ([]entity.GameSpin, error)
+	CloseOpenRounds(operatorID, userID int) ([]*Round, error)
 }
 
 type gameService struct {
-	generator *Generator
+	db         *gorm.DB
+	generator  *Generator
+	paytable   PaytableService
+	wallet     WalletService
+	currencies CurrencyService
+	operators  OperatorService
+	config     *config.Config
+	modifiers  []domain.WildModifier
 }
 
-func NewGameService() GameService {
-	return &gameService{
-		generator: NewGenerator(),
+func NewGameService(db *gorm.DB, cfg *config.Config, paytable PaytableService, wallet WalletService, currencies CurrencyService, operators OperatorService) GameService {
+	var modifiers []domain.WildModifier
+	for _, value := range cfg.Game.WildModifiers {
+		modifier, err := domain.ParseWildModifier(value)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		modifiers = append(modifiers, modifier)
+	}
+
+	s := &gameService{
+		db:         db,
+		generator:  NewGenerator(),
+		paytable:   paytable,
+		wallet:     wallet,
+		currencies: currencies,
+		operators:  operators,
+		config:     cfg,
+		modifiers:  modifiers,
 	}
+	// 啟動中斷遊戲局的自動結算及派彩重試
+	go s.settleRounds()
+	return s
 }
 
 func NewGenerator() *Generator {
+	return NewGeneratorFromDefinition(domain.DefaultGameDefinition())
+}
+
+// NewGeneratorFromDefinition 根據遊戲定義建立盤面生成器
+func NewGeneratorFromDefinition(definition domain.GameDefinition) *Generator {
 	return &Generator{
-		symbols: domain.GetSymbolList(),
-		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		symbols:    definition.Symbols,
+		coinValues: definition.CoinValues,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
@@ -47,7 +117,733 @@ func (s *gameService) GenerateBoardWithBias() models.Board {
 	return s.generator.GenerateBoardWithBias()
 }
 
+// Spin 開始新的一局並進行主遊戲旋轉
+// 帶有 idempotencyKey 的重複請求會返回原始結果而不會再次旋轉
+func (s *gameService) Spin(operatorID, userID int, betAmount money.Money, idempotencyKey string) (*SpinResult, error) {
+	if !betAmount.IsPositive() {
+		return nil, ErrInvalidBet
+	}
+	if err := s.operators.CheckGame(operatorID, domain.DefaultGameID); err != nil {
+		return nil, err
+	}
+	if err := checkActiveUser(s.db, operatorID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.currencies.ValidateBet(operatorID, domain.DefaultGameID, betAmount); err != nil {
+		return nil, err
+	}
+	// 派彩上限無法換算為下注貨幣時不接受下注，避免遊戲局不受上限限制
+	maxWin, err := s.maxWin(betAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新的遊戲局不需要鎖定，重複的冪等鍵由唯一索引擋下
+	requestHash := hashRequest(map[string]interface{}{
+		"betAmount": betAmount,
+	})
+
+	if idempotencyKey != "" {
+		result, err := s.findSpin(userID, idempotencyKey, requestHash)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+	}
+
+	// 新的遊戲局使用目前生效的修訂版，後續步驟沿用同一修訂版
+	rev, err := s.paytable.Active(domain.DefaultGameID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	round := &Round{
+		ID:           utils.NewID(),
+		OperatorID:   operatorID,
+		UserID:       userID,
+		RevisionHash: rev.Hash,
+		BetAmount:    betAmount,
+		MaxWin:       maxWin,
+		TotalWin:     money.Zero(betAmount.Currency()),
+		JackpotWin:   money.Zero(betAmount.Currency()),
+		Paid:         money.Zero(betAmount.Currency()),
+		Status:       RoundOpen,
+		Modifiers:    s.modifiers,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if s.config.Game.FruitMachine {
+		s.playFruitSpin(round, rev)
+	} else {
+		s.playStep(round, rev, EventSpin)
+	}
+	s.finishStep(round)
+
+	bet := Transfer{
+		TransactionID: round.ID + "-bet",
+		OperatorID:    operatorID,
+		UserID:        userID,
+		RoundID:       round.ID,
+		Amount:        betAmount,
+	}
+
+	var spinID int64
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		balance, err := s.wallet.Debit(tx, bet)
+		if err != nil {
+			return err
+		}
+		round.Events[0].Balance = &balance
+		if err := saveRound(tx, round); err != nil {
+			return err
+		}
+		spinID, err = saveSpin(tx, operatorID, userID, idempotencyKey, requestHash, newSpinResult(round, balance))
+		return err
+	})
+	if err != nil {
+		// 外部錢包的扣款不會隨資料庫交易回滾，扣款請求逾時或失敗時營運商也可能已經扣款，
+		// 因此一律通知營運商撤銷；沒有該筆扣款時視為成功
+		if rollbackErr := s.wallet.Rollback(bet); rollbackErr != nil {
+			log.Printf("Failed to roll back bet of round %s: %v", round.ID, rollbackErr)
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return s.findSpin(userID, idempotencyKey, requestHash)
+		}
+		return nil, err
+	}
+
+	paid, balance, err := s.payout(round.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := newSpinResult(paid, balance)
+
+	// 以派彩後的結果更新旋轉紀錄，重複請求返回與原始回應相同的內容
+	if err := updateSpinResult(s.db, spinID, result); err != nil {
+		log.Printf("Failed to update spin result of round %s: %v", round.ID, err)
+	}
+	return result, nil
+}
+
+// findSpin 根據冪等鍵查詢已儲存的旋轉結果，請求內容不同時返回衝突錯誤
+func (s *gameService) findSpin(userID int, idempotencyKey, requestHash string) (*SpinResult, error) {
+	var record entity.GameSpin
+	if err := s.db.Where("user_id = ? AND idempotency_key = ?", userID, idempotencyKey).First(&record).Error; err != nil {
+		return nil, err
+	}
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyConflict
+	}
+
+	var result SpinResult
+	if err := json.Unmarshal([]byte(record.Result), &result); err != nil {
+		return nil, err
+	}
+	result.Replayed = true
+	return &result, nil
+}
+
+// saveSpin 儲存旋轉紀錄及冪等鍵，返回紀錄 ID
+func saveSpin(db *gorm.DB, operatorID, userID int, idempotencyKey, requestHash string, result *SpinResult) (int64, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return 0, err
+	}
+
+	record := &entity.GameSpin{
+		OperatorID:   operatorID,
+		UserID:       userID,
+		RoundID:      result.Round.ID,
+		RevisionHash: result.Round.RevisionHash,
+		RequestHash:  requestHash,
+		Currency:     string(result.Round.BetAmount.Currency()),
+		BetAmount:    result.Round.BetAmount.Minor(),
+		WinAmount:    result.Event.WinAmount.Minor(),
+		Result:       string(data),
+	}
+	if idempotencyKey != "" {
+		record.IdempotencyKey = &idempotencyKey
+	}
+
+	if err := db.Create(record).Error; err != nil {
+		return 0, err
+	}
+	return record.ID, nil
+}
+
+// updateSpinResult 更新旋轉紀錄保存的結果
+func updateSpinResult(db *gorm.DB, spinID int64, result *SpinResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return db.Model(&entity.GameSpin{ID: spinID}).Update("result", string(data)).Error
+}
+
+// hashRequest 計算請求內容的雜湊，用於偵測相同冪等鍵的不同請求
+func hashRequest(payload interface{}) string {
+	data, _ := json.Marshal(payload)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// PlayRound 進行遊戲局中的下一次免費旋轉或 Hold and Spin 重轉
+func (s *gameService) PlayRound(userID int, roundID string) (*SpinResult, error) {
+	return s.playOpenRound(userID, roundID, func(round *Round, rev *Revision) error {
+		if round.Feature == FeatureNudgeHold {
+			return ErrInvalidAction
+		}
+		s.playFeature(round, rev)
+		return nil
+	})
+}
+
+// OpenRounds 返回使用者所有尚未完成的遊戲局及其最近一次的步驟，供重新進入遊戲時恢復
+func (s *gameService) OpenRounds(userID int) ([]*SpinResult, error) {
+	rounds, err := findRounds(s.db.Where("user_id = ? AND status = ?", userID, RoundOpen))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*SpinResult, 0, len(rounds))
+	for _, round := range rounds {
+		balance, err := s.wallet.Balance(userID, round.BetAmount.Currency())
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, newSpinResult(round, balance))
+	}
+	return results, nil
+}
+
+// GetRound 讀取營運商旗下遊戲局的完整紀錄
+func (s *gameService) GetRound(operatorID int, roundID string) (*Round, error) {
+	return loadRound(s.db.Where("operator_id = ?", operatorID), roundID)
+}
+
+// playOpenRound 鎖定使用者進行中的遊戲局並進行一個步驟後儲存，提交後再派彩
+// 遊戲局在交易期間保持鎖定，同一遊戲局的請求依序處理，不同遊戲局互不影響
+func (s *gameService) playOpenRound(userID int, roundID string, play func(round *Round, rev *Revision) error) (*SpinResult, error) {
+	var round *Round
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		round, err = lockRound(tx, roundID)
+		if err != nil {
+			return err
+		}
+		if round.UserID != userID {
+			return ErrRoundNotFound
+		}
+		if round.Status != RoundOpen {
+			return ErrRoundCompleted
+		}
+		rev, err := s.paytable.Get(round.RevisionHash)
+		if err != nil {
+			return err
+		}
+
+		if err := play(round, rev); err != nil {
+			return err
+		}
+		s.finishStep(round)
+		return saveRound(tx, round)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paid, balance, err := s.payout(round.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newSpinResult(paid, balance), nil
+}
+
+// payout 派發遊戲局已儲存但尚未派發的獎金，並記錄各步驟結算後的餘額，返回派彩後的遊戲局及餘額
+// 呼叫端需在遊戲局提交後呼叫，外部錢包的派彩無法隨資料庫交易回滾；
+// 每個步驟的獎金以各自的交易 ID 派發，失敗後重試不會重複派彩，最高獎金限制已在 finishStep 中套用
+func (s *gameService) payout(roundID string) (*Round, money.Money, error) {
+	var round *Round
+	var balance money.Money
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		round, err = lockRound(tx, roundID)
+		if err != nil {
+			return err
+		}
+
+		transfer := Transfer{
+			OperatorID: round.OperatorID,
+			UserID:     round.UserID,
+			RoundID:    round.ID,
+		}
+		unpaid := round.unpaidEvents()
+		credited := make(map[int]money.Money, len(unpaid))
+		for _, event := range unpaid {
+			transfer.TransactionID = fmt.Sprintf("%s-win-%d", round.ID, event.Seq)
+			transfer.Amount = event.WinAmount
+			if balance, err = s.wallet.Credit(tx, transfer); err != nil {
+				return err
+			}
+			credited[event.Seq] = balance
+		}
+		if len(unpaid) == 0 {
+			// 沒有待派發的獎金時只查詢餘額
+			transfer.Amount = money.Zero(round.BetAmount.Currency())
+			if balance, err = s.wallet.Credit(tx, transfer); err != nil {
+				return err
+			}
+		}
+
+		changed := round.recordBalances(credited, balance)
+		if len(unpaid) == 0 && !changed {
+			return nil
+		}
+		round.Paid = round.TotalWin
+		return saveRound(tx, round)
+	})
+	if err != nil {
+		return nil, money.Money{}, err
+	}
+	return round, balance, nil
+}
+
+// playFeature 進行下一次 Hold and Spin 重轉或免費旋轉
+func (s *gameService) playFeature(round *Round, rev *Revision) {
+	switch round.Feature {
+	case FeatureHoldAndSpin:
+		s.playRespin(round, rev)
+	case FeatureFreeSpins:
+		round.FreeSpinsLeft--
+		s.playStep(round, rev, EventFreeSpin)
+	case FeatureNudgeHold:
+		// 以目前的輪帶位置結算，視同放棄 Hold 或 Nudge
+		s.playFruitAction(round, rev, EventNudge)
+	}
+}
+
+// settleRounds 定期自動結算超過時限未有動作的遊戲局，並重試派彩失敗的獎金
+func (s *gameService) settleRounds() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if s.config.Game.RoundTimeout > 0 {
+			s.completeAbandonedRounds()
+		}
+		s.retryPayouts()
+	}
+}
+
+// completeAbandonedRounds 自動結算超過時限未有動作的遊戲局
+func (s *gameService) completeAbandonedRounds() {
+	rounds, err := findAbandonedRounds(s.db, time.Now().Add(-s.config.Game.RoundTimeout))
+	if err != nil {
+		log.Printf("Failed to find abandoned rounds: %v", err)
+		return
+	}
+
+	for _, round := range rounds {
+		if err := s.autoComplete(round.ID); err != nil {
+			log.Printf("Failed to auto-complete round %s: %v", round.ID, err)
+		}
+	}
+}
+
+// retryPayouts 重新派發已儲存但派彩失敗的遊戲局獎金
+func (s *gameService) retryPayouts() {
+	rounds, err := findUnpaidRounds(s.db, time.Now().Add(-payoutRetryDelay))
+	if err != nil {
+		log.Printf("Failed to find unpaid rounds: %v", err)
+		return
+	}
+
+	for _, round := range rounds {
+		if _, _, err := s.payout(round.ID); err != nil {
+			log.Printf("Failed to pay out round %s: %v", round.ID, err)
+		}
+	}
+}
+
+// autoComplete 代替玩家完成遊戲局中剩餘的特色玩法
+func (s *gameService) autoComplete(roundID string) error {
+	completed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 鎖定後重新讀取，避免玩家在查詢後恢復了遊戲局
+		round, err := lockRound(tx, roundID)
+		if err != nil {
+			return err
+		}
+		if round.Status != RoundOpen || time.Since(round.UpdatedAt) < s.config.Game.RoundTimeout {
+			return nil
+		}
+
+		log.Printf("Auto-completing abandoned round %s for user %d", round.ID, round.UserID)
+		completed = true
+		return s.completeRound(tx, round)
+	})
+	if err != nil || !completed {
+		return err
+	}
+	_, _, err = s.payout(roundID)
+	return err
+}
+
+// completeRound 結算遊戲局剩餘的特色玩法並儲存，呼叫端需在交易中以 lockRound 鎖定遊戲局，提交後再派彩
+func (s *gameService) completeRound(tx *gorm.DB, round *Round) error {
+	rev, err := s.paytable.Get(round.RevisionHash)
+	if err != nil {
+		return err
+	}
+
+	for round.Status == RoundOpen {
+		s.playFeature(round, rev)
+		s.finishStep(round)
+	}
+	round.AutoCompleted = true
+
+	return saveRound(tx, round)
+}
+
+// RecentSpins 返回營運商旗下使用者最近的旋轉紀錄
+func (s *gameService) RecentSpins(operatorID, userID, limit int) ([]entity.GameSpin, error) {
+	var spins []entity.GameSpin
+	err := s.db.Where("operator_id = ? AND user_id = ?", operatorID, userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&spins).Error
+	return spins, err
+}
+
+// CloseOpenRounds 由管理者強制結算使用者所有進行中的遊戲局，剩餘的特色玩法照常進行並派彩
+func (s *gameService) CloseOpenRounds(operatorID, userID int) ([]*Round, error) {
+	open, err := findRounds(s.db.Where("operator_id = ? AND user_id = ? AND status = ?", operatorID, userID, RoundOpen))
+	if err != nil {
+		return nil, err
+	}
+
+	rounds := make([]*Round, 0, len(open))
+	for _, candidate := range open {
+		var round *Round
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			// 鎖定後重新讀取，玩家可能在查詢後完成了遊戲局
+			locked, err := lockRound(tx, candidate.ID)
+			if err != nil || locked.Status != RoundOpen {
+				return err
+			}
+			locked.ForceClosed = true
+			if err := s.completeRound(tx, locked); err != nil {
+				return err
+			}
+			round = locked
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if round == nil {
+			continue
+		}
+		log.Printf("Force-closed round %s for user %d", round.ID, round.UserID)
+
+		// 派彩失敗時遊戲局已結算，獎金由定期派彩重試補發
+		if paid, _, err := s.payout(round.ID); err != nil {
+			log.Printf("Failed to pay out force-closed round %s: %v", round.ID, err)
+		} else {
+			round = paid
+		}
+		rounds = append(rounds, round)
+	}
+	return rounds, nil
+}
+
+// Hold 鎖定指定的輪軸並重轉其餘輪軸
+func (s *gameService) Hold(userID int, roundID string, columns []int) (*SpinResult, error) {
+	return s.playOpenRound(userID, roundID, func(round *Round, rev *Revision) error {
+		if !awaitingFruitAction(round) || !round.Offer.Hold || len(columns) >= len(round.Stops) || !validColumns(columns, true) {
+			return ErrInvalidAction
+		}
+
+		held := make(map[int]bool)
+		for _, col := range columns {
+			held[col] = true
+		}
+		for col := range round.Stops {
+			if !held[col] {
+				round.Stops[col] = rev.Generator.RandomStop(len(rev.Strips[col]))
+			}
+		}
+
+		s.playFruitAction(round, rev, EventHold)
+		return nil
+	})
+}
+
+// Nudge 依序將指定的輪軸沿輪帶移動一格，空列表代表放棄 Nudge
+func (s *gameService) Nudge(userID int, roundID string, columns []int) (*SpinResult, error) {
+	return s.playOpenRound(userID, roundID, func(round *Round, rev *Revision) error {
+		if !awaitingFruitAction(round) || len(columns) > round.Offer.Nudges || !validColumns(columns, false) {
+			return ErrInvalidAction
+		}
+
+		for _, col := range columns {
+			length := len(rev.Strips[col])
+			round.Stops[col] = (round.Stops[col] - 1 + length) % length
+		}
+
+		s.playFruitAction(round, rev, EventNudge)
+		return nil
+	})
+}
+
+// awaitingFruitAction 檢查遊戲局是否正在等待玩家選擇 Hold 或 Nudge
+func awaitingFruitAction(round *Round) bool {
+	return round.Feature == FeatureNudgeHold && round.Offer != nil
+}
+
+// validColumns 檢查輪軸編號是否有效，unique 為 true 時不允許重複
+func validColumns(columns []int, unique bool) bool {
+	seen := make(map[int]bool)
+	for _, col := range columns {
+		if col < 0 || col > 2 || (unique && seen[col]) {
+			return false
+		}
+		seen[col] = true
+	}
+	return true
+}
+
+// playFruitSpin 以輪帶進行水果機模式的主遊戲旋轉，未中獎時可能給予 Hold 或 Nudge
+func (s *gameService) playFruitSpin(round *Round, rev *Revision) {
+	for col := range round.Stops {
+		round.Stops[col] = rev.Generator.RandomStop(len(rev.Strips[col]))
+	}
+
+	board := boardFromStops(rev.Strips, round.Stops)
+	win := rev.Checker.CheckWin(board, round.BetAmount)
+
+	if win.Payout.IsZero() && rev.Generator.Intn(100) < s.config.Game.NudgeHoldChance {
+		if rev.Generator.Intn(2) == 0 {
+			round.Offer = &FruitOffer{Hold: true}
+		} else if s.config.Game.MaxNudges > 0 {
+			round.Offer = &FruitOffer{Nudges: rev.Generator.Intn(s.config.Game.MaxNudges) + 1}
+		}
+	}
+
+	round.addEvent(EventSpin, board, win, nil)
+}
+
+// playFruitAction 以目前的輪帶位置計算玩家選擇後的最終結果
+func (s *gameService) playFruitAction(round *Round, rev *Revision, eventType string) {
+	round.Offer = nil
+	board := boardFromStops(rev.Strips, round.Stops)
+	round.addEvent(eventType, board, rev.Checker.CheckWin(board, round.BetAmount), nil)
+}
+
+// boardFromStops 根據每條輪帶的停止位置組成盤面
+func boardFromStops(strips [3][]domain.Symbol, stops [3]int) models.Board {
+	var board models.Board
+	for col, strip := range strips {
+		for row := 0; row < 3; row++ {
+			board[row][col] = strip[(stops[col]+row)%len(strip)]
+		}
+	}
+	return board
+}
+
+// newSpinResult 複製遊戲局狀態，避免返回後被後續步驟修改
+func newSpinResult(round *Round, balance money.Money) *SpinResult {
+	snapshot := *round
+	return &SpinResult{Round: &snapshot, Event: round.lastEvent(), Balance: balance}
+}
+
+// playStep 生成盤面、合併覆蓋層並計算中獎結果
+func (s *gameService) playStep(round *Round, rev *Revision, eventType string) {
+	board, landed := s.spinBoard(round, rev)
+	win := rev.Checker.CheckWin(board, round.BetAmount)
+
+	var coins []CoinWin
+	if eventType == EventSpin {
+		// 主遊戲中落下足夠的百搭觸發免費旋轉
+		if landed >= s.config.Game.FreeSpinTrigger {
+			round.FreeSpinsLeft += s.config.Game.FreeSpins
+		}
+
+		// 主遊戲中落下足夠的金幣觸發 Hold and Spin
+		if len(board.GetAllPositions(domain.Coin)) >= s.config.Game.HoldAndSpinTrigger {
+			s.startHoldAndSpin(round, rev, board)
+			coins = round.lockedCoins()
+		}
+	}
+
+	round.addEvent(eventType, board, win, coins)
+}
+
+// startHoldAndSpin 鎖定盤面上的金幣並給予重轉次數
+func (s *gameService) startHoldAndSpin(round *Round, rev *Revision, board models.Board) {
+	s.lockNewCoins(round, rev, board)
+	round.RespinsLeft = s.config.Game.HoldAndSpinRespins
+}
+
+// playRespin 進行一次 Hold and Spin 重轉，結束時派發所有金幣的面額
+func (s *gameService) playRespin(round *Round, rev *Revision) {
+	round.RespinsLeft--
+
+	board := round.Coins.Apply(rev.Generator.GenerateBoard())
+	if s.lockNewCoins(round, rev, board) > 0 {
+		round.RespinsLeft = s.config.Game.HoldAndSpinRespins
+	}
+
+	win := WinResult{Payout: money.Zero(round.BetAmount.Currency())}
+	if round.RespinsLeft == 0 || round.Coins.Count() == len(board)*len(board[0]) {
+		round.RespinsLeft = 0
+		for _, coin := range round.lockedCoins() {
+			win.Payout = win.Payout.Add(coin.Amount)
+			if coin.Jackpot != domain.JackpotNone {
+				round.JackpotWin = round.JackpotWin.Add(coin.Amount)
+			}
+		}
+	}
+
+	round.addEvent(EventRespin, board, win, round.lockedCoins())
+}
+
+// lockNewCoins 鎖定盤面上新落下的金幣並抽取面額，返回新鎖定的數量
+func (s *gameService) lockNewCoins(round *Round, rev *Revision, board models.Board) int {
+	landed := 0
+	for _, pos := range board.GetAllPositions(domain.Coin) {
+		if round.Coins.Locked[pos[0]][pos[1]] {
+			continue
+		}
+		round.Coins.Set(pos[0], pos[1], domain.Coin)
+		round.CoinValues[pos[0]][pos[1]] = rev.Generator.RandomCoinValue()
+		landed++
+	}
+	return landed
+}
+
+// spinBoard 生成盤面並套用遊戲局的百搭效果，同時返回本次新落下的百搭數量
+func (s *gameService) spinBoard(round *Round, rev *Revision) (models.Board, int) {
+	sticky := round.HasModifier(domain.StickyWild)
+	walking := round.HasModifier(domain.WalkingWild)
+
+	if walking {
+		round.Overlay.ShiftLeft()
+	}
+
+	generated := rev.Generator.GenerateBoard()
+	landed := len(generated.GetAllPositions(domain.Wild))
+	board := round.Overlay.Apply(generated)
+
+	if round.HasModifier(domain.ExpandingWild) {
+		board = expandWilds(board)
+	}
+
+	// 非黏性的移動百搭只保留本次盤面上的百搭，下次旋轉再往左移動
+	if walking && !sticky {
+		round.Overlay = models.Overlay{}
+	}
+	if sticky || walking {
+		for _, pos := range board.GetAllPositions(domain.Wild) {
+			round.Overlay.Set(pos[0], pos[1], domain.Wild)
+		}
+	}
+
+	return board, landed
+}
+
+// finishStep 套用最高獎金限制，並根據剩餘的重轉及免費旋轉更新遊戲局狀態，Hold and Spin 優先於免費旋轉
+func (s *gameService) finishStep(round *Round) {
+	s.applyWinCap(round)
+
+	switch {
+	case round.Offer != nil:
+		round.Feature = FeatureNudgeHold
+	case round.RespinsLeft > 0:
+		round.Feature = FeatureHoldAndSpin
+	case round.FreeSpinsLeft > 0:
+		round.Feature = FeatureFreeSpins
+	default:
+		round.Feature = FeatureNone
+		round.Status = RoundCompleted
+	}
+}
+
+// applyWinCap 在所有特色玩法之後以開局時凍結的上限限制單局總獎金，達到上限時提前結束遊戲局
+// 超過的部分從最後一個步驟的獎金及其中獎線、金幣明細扣除，回放時看到的是實際派發的金額
+func (s *gameService) applyWinCap(round *Round) {
+	if round.MaxWin == nil {
+		return
+	}
+	limit := *round.MaxWin
+
+	capped := round.TotalWin
+	if !s.config.Game.MaxWinIncludesJackpots {
+		capped = capped.Sub(round.JackpotWin)
+	}
+	if capped.Cmp(limit) < 0 {
+		return
+	}
+
+	last := &round.Events[len(round.Events)-1]
+	cut, jackpotCut := last.capWin(capped.Sub(limit))
+	last.MaxWinReached = true
+	round.JackpotWin = round.JackpotWin.Sub(jackpotCut)
+	round.TotalWin = round.TotalWin.Sub(cut)
+	round.MaxWinReached = true
+
+	// 放棄剩餘的特色玩法
+	round.FreeSpinsLeft = 0
+	round.RespinsLeft = 0
+	round.Offer = nil
+}
+
+// maxWin 計算單局最高獎金，取倍數上限與派彩上限中較小者，兩者皆不限制時返回 nil
+// 派彩上限以基準貨幣設定，依匯率換算為下注貨幣；只在開局時計算，之後的步驟不受匯率變動影響
+// 派彩上限設定無效或無法換算時返回錯誤，由呼叫方拒絕下注而不是退回只依倍數計算的上限
+func (s *gameService) maxWin(betAmount money.Money) (*money.Money, error) {
+	limit := money.Zero(betAmount.Currency())
+	if multiplier := s.config.Game.MaxWinMultiplier; multiplier > 0 {
+		limit = betAmount.Mul(multiplier, money.RoundDown)
+	}
+
+	if value := s.config.Game.MaxRoundLiability; value != "" {
+		liability, err := money.Parse(value, s.currencies.BaseCurrency())
+		if err != nil {
+			return nil, fmt.Errorf("invalid max round liability %q: %w", value, err)
+		}
+		if liability.IsPositive() {
+			liability, err = s.currencies.FromBase(liability, betAmount.Currency())
+			if err != nil {
+				return nil, err
+			}
+			if limit.IsZero() || liability.Cmp(limit) < 0 {
+				limit = liability
+			}
+		}
+	}
+
+	if !limit.IsPositive() {
+		return nil, nil
+	}
+	return &limit, nil
+}
+
+// expandWilds 將百搭符號擴展至所在的整軸
+func expandWilds(board models.Board) models.Board {
+	for _, pos := range board.GetAllPositions(domain.Wild) {
+		for i := 0; i < 3; i++ {
+			board[i][pos[1]] = domain.Wild
+		}
+	}
+	return board
+}
+
 func (g *Generator) GenerateBoard() models.Board {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	var board models.Board
 	totalWeight := 0
 	for _, symbol := range g.symbols {
@@ -71,6 +867,9 @@ func (g *Generator) GenerateBoard() models.Board {
 }
 
 func (g *Generator) GenerateBoardWithBias() models.Board {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	var board models.Board
 
 	mainSymbol := g.symbols[g.rng.Intn(len(g.symbols))].Symbol
@@ -107,6 +906,41 @@ func (g *Generator) GenerateBoardWithBias() models.Board {
 	return board
 }
 
+// RandomStop 隨機抽取輪帶的停止位置
+func (g *Generator) RandomStop(length int) int {
+	return g.Intn(length)
+}
+
+// Intn 返回 [0, n) 之間的隨機整數
+func (g *Generator) Intn(n int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.Intn(n)
+}
+
+// RandomCoinValue 根據權重抽取金幣面額
+func (g *Generator) RandomCoinValue() domain.CoinValue {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	values := g.coinValues
+	totalWeight := 0
+	for _, value := range values {
+		totalWeight += value.Weight
+	}
+
+	weight := g.rng.Intn(totalWeight)
+	currentWeight := 0
+	for _, value := range values {
+		currentWeight += value.Weight
+		if weight < currentWeight {
+			return value
+		}
+	}
+	return values[0]
+}
+
+// randomSymbol 根據權重抽取符號，呼叫端需持有 g.mu
 func (g *Generator) randomSymbol() domain.Symbol {
 	totalWeight := 0
 	for _, symbol := range g.symbols {
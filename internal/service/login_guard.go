@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginGuard 記錄登入失敗次數，依電話及來源 IP 要求等待或暫時鎖定
+// 不存在的電話一樣計數，回應不會透露電話是否已註冊
+type LoginGuard interface {
+	Check(operatorID int, phone, ip string) error
+	Fail(operatorID int, phone, ip string) error
+	Succeed(operatorID int, phone string) error
+}
+
+type loginGuard struct {
+	db     *gorm.DB
+	config *config.Config
+	audit  AuditService
+}
+
+func NewLoginGuard(db *gorm.DB, cfg *config.Config, audit AuditService) LoginGuard {
+	return &loginGuard{
+		db:     db,
+		config: cfg,
+		audit:  audit,
+	}
+}
+
+// Check 電話或 IP 在等待或鎖定期間時返回 ThrottledError
+func (g *loginGuard) Check(operatorID int, phone, ip string) error {
+	var records []entity.LoginThrottle
+	err := g.db.Where("key IN ?", []string{phoneKey(operatorID, phone), ipKey(ip)}).
+		Where("locked_until > ?", time.Now()).
+		Find(&records).Error
+	if err != nil {
+		return err
+	}
+
+	var retryAfter time.Duration
+	for _, record := range records {
+		if wait := time.Until(*record.LockedUntil); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return &ThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Fail 記錄一次登入失敗
+// 同一電話連續失敗達 DelayAfter 次後，每次失敗的等待時間加倍；達到上限時鎖定並寫入稽核紀錄
+func (g *loginGuard) Fail(operatorID int, phone, ip string) error {
+	cfg := g.config.Login
+	failures, lockedUntil, err := g.recordFailure(phoneKey(operatorID, phone), cfg.MaxFailures, true)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		g.recordLockout(operatorID, ip, map[string]interface{}{
+			"phone":       phone,
+			"failures":    failures,
+			"lockedUntil": lockedUntil,
+		})
+	}
+
+	failures, lockedUntil, err = g.recordFailure(ipKey(ip), cfg.IPMaxFailures, false)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		g.recordLockout(operatorID, ip, map[string]interface{}{
+			"failures":    failures,
+			"lockedUntil": lockedUntil,
+		})
+	}
+	return nil
+}
+
+// Succeed 登入成功後清除電話的失敗計數，IP 可能由多位使用者共用，計數保留到過期
+func (g *loginGuard) Succeed(operatorID int, phone string) error {
+	return g.db.Where("key = ?", phoneKey(operatorID, phone)).Delete(&entity.LoginThrottle{}).Error
+}
+
+// recordFailure 增加失敗次數並設定等待或鎖定期限，返回失敗次數及本次失敗造成的鎖定期限
+func (g *loginGuard) recordFailure(key string, maxFailures int, delay bool) (int, *time.Time, error) {
+	cfg := g.config.Login
+	var failures int
+	var lockedUntil *time.Time
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&entity.LoginThrottle{Key: key, LastFailureAt: time.Now()}).Error
+		if err != nil {
+			return err
+		}
+
+		var record entity.LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).Take(&record).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if now.Sub(record.LastFailureAt) > cfg.FailureWindow {
+			record.Failures = 0
+		}
+		record.Failures++
+		record.LastFailureAt = now
+
+		switch {
+		case maxFailures > 0 && record.Failures >= maxFailures:
+			until := now.Add(cfg.LockoutTTL)
+			record.LockedUntil = &until
+			lockedUntil = &until
+		case delay && cfg.DelayAfter > 0 && record.Failures >= cfg.DelayAfter:
+			until := now.Add(loginDelay(record.Failures-cfg.DelayAfter, cfg.DelayBase, cfg.MaxDelay))
+			record.LockedUntil = &until
+		}
+
+		failures = record.Failures
+		return tx.Model(&record).
+			Select("failures", "last_failure_at", "locked_until").
+			Updates(&record).Error
+	})
+	return failures, lockedUntil, err
+}
+
+// recordLockout 寫入鎖定的稽核紀錄，寫入失敗不影響登入流程
+func (g *loginGuard) recordLockout(operatorID int, ip string, detail map[string]interface{}) {
+	err := g.audit.Record(AuditEntry{
+		OperatorID: operatorID,
+		Action:     entity.AuditLoginLocked,
+		IP:         ip,
+		Detail:     detail,
+	})
+	if err != nil {
+		log.Printf("Failed to record login lockout: %v", err)
+	}
+}
+
+// loginDelay 返回第 n 次 (從 0 開始) 要求等待的時間
+func loginDelay(n int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 0; i < n && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+func phoneKey(operatorID int, phone string) string {
+	return fmt.Sprintf("phone:%d:%s", operatorID, phone)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
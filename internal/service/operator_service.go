@@ -0,0 +1,428 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/pkg/money"
+	"passontw-slot-game/pkg/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidAPIKey    = errors.New("invalid api key")
+	ErrOperatorDisabled = errors.New("operator is disabled")
+	ErrOperatorExists   = errors.New("operator code already exists")
+	ErrOperatorNotFound = errors.New("operator not found")
+	ErrGameNotFound     = errors.New("game not found")
+	ErrGameDisabled     = errors.New("game is not available for this operator")
+	ErrInvalidPlayerID  = errors.New("external player id is required")
+)
+
+// operatorPlayerNameLength 使用者名稱欄位長度上限
+const operatorPlayerNameLength = 20
+
+// SessionRequest 營運商建立遊戲 session 的參數
+type SessionRequest struct {
+	ExternalPlayerID string
+	Nickname         string
+	GameID           string
+	Currency         money.Currency
+	Language         string
+	LobbyURL         string
+}
+
+// JurisdictionSettings 營運商所在的司法管轄區及自動旋轉的限制
+type JurisdictionSettings struct {
+	Jurisdiction     string
+	AutoplayDisabled bool
+	AutoplayMaxSpins int // 0 代表不限制
+}
+
+// GameSettings 營運商對遊戲的設定
+type GameSettings struct {
+	OperatorID int
+	GameID     string
+	Enabled    bool
+	Branding   map[string]string
+}
+
+// Session 營運商玩家的遊戲 session
+type Session struct {
+	UserID    int
+	Token     string
+	ExpiresAt time.Time
+	LaunchURL string
+}
+
+// OperatorService 管理營運商（租戶）、營運商的遊戲設定及營運商玩家的 session
+type OperatorService interface {
+	PlatformOperatorID() int
+	CreateOperator(code, name string) (*entity.Operator, string, error)
+	ListOperators() ([]entity.Operator, error)
+	GetOperator(operatorID int) (*entity.Operator, error)
+	SetWallet(operatorID int, walletURL, secret string) error
+	SetJurisdiction(operatorID int, settings JurisdictionSettings) error
+	Authenticate(apiKey string) (*entity.Operator, error)
+	GameSettings(operatorID int, gameID string) (*GameSettings, error)
+	SetGameSettings(settings GameSettings) error
+	CheckGame(operatorID int, gameID string) error
+	CreateSession(operator *entity.Operator, request SessionRequest) (*Session, error)
+}
+
+type operatorService struct {
+	db         *gorm.DB
+	config     *config.Config
+	tokens     TokenService
+	currencies CurrencyService
+	platformID int
+}
+
+func NewOperatorService(db *gorm.DB, cfg *config.Config, tokens TokenService, currencies CurrencyService) (OperatorService, error) {
+	s := &operatorService{
+		db:         db,
+		config:     cfg,
+		tokens:     tokens,
+		currencies: currencies,
+	}
+	if err := s.seedPlatformOperator(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// seedPlatformOperator 建立平台本身的營運商，直接註冊的使用者都屬於此營運商
+func (s *operatorService) seedPlatformOperator() error {
+	// 平台不透過 API 金鑰呼叫整合介面，寫入無人知道的隨機金鑰雜湊
+	platform := entity.Operator{
+		Code:       entity.PlatformOperatorCode,
+		Name:       "Platform",
+		APIKeyHash: hashAPIKey(utils.NewID()),
+		Status:     entity.OperatorStatusActive,
+	}
+	err := s.db.Where(entity.Operator{Code: entity.PlatformOperatorCode}).
+		Attrs(platform).
+		FirstOrCreate(&platform).Error
+	if err != nil {
+		return err
+	}
+	s.platformID = platform.ID
+	return nil
+}
+
+// PlatformOperatorID 返回平台營運商的 ID
+func (s *operatorService) PlatformOperatorID() int {
+	return s.platformID
+}
+
+// CreateOperator 建立營運商，API 金鑰明文只在建立時返回一次
+func (s *operatorService) CreateOperator(code, name string) (*entity.Operator, string, error) {
+	apiKey := "sk_" + utils.NewID()
+	operator := &entity.Operator{
+		Code:       code,
+		Name:       name,
+		APIKeyHash: hashAPIKey(apiKey),
+		Status:     entity.OperatorStatusActive,
+	}
+	if err := s.db.Create(operator).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, "", ErrOperatorExists
+		}
+		return nil, "", err
+	}
+	return operator, apiKey, nil
+}
+
+// ListOperators 返回所有營運商
+func (s *operatorService) ListOperators() ([]entity.Operator, error) {
+	var operators []entity.Operator
+	if err := s.db.Order("id").Find(&operators).Error; err != nil {
+		return nil, err
+	}
+	return operators, nil
+}
+
+// GetOperator 以 ID 查詢營運商
+func (s *operatorService) GetOperator(operatorID int) (*entity.Operator, error) {
+	var operator entity.Operator
+	err := s.db.Take(&operator, operatorID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOperatorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &operator, nil
+}
+
+// SetWallet 設定營運商外部錢包的網址及請求簽章密鑰，設定網址後該營運商的玩家改用外部錢包
+func (s *operatorService) SetWallet(operatorID int, walletURL, secret string) error {
+	result := s.db.Model(&entity.Operator{}).Where("id = ?", operatorID).Updates(map[string]interface{}{
+		"wallet_url":    strings.TrimRight(walletURL, "/"),
+		"wallet_secret": secret,
+		"updated_at":    time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOperatorNotFound
+	}
+	return nil
+}
+
+// SetJurisdiction 設定營運商的司法管轄區及自動旋轉的限制，新的設定在下一次開始自動旋轉時生效
+func (s *operatorService) SetJurisdiction(operatorID int, settings JurisdictionSettings) error {
+	result := s.db.Model(&entity.Operator{}).Where("id = ?", operatorID).Updates(map[string]interface{}{
+		"jurisdiction":       strings.ToUpper(settings.Jurisdiction),
+		"autoplay_disabled":  settings.AutoplayDisabled,
+		"autoplay_max_spins": settings.AutoplayMaxSpins,
+		"updated_at":         time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOperatorNotFound
+	}
+	return nil
+}
+
+// Authenticate 以 API 金鑰查詢營運商
+func (s *operatorService) Authenticate(apiKey string) (*entity.Operator, error) {
+	if apiKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var operator entity.Operator
+	err := s.db.Where("api_key_hash = ?", hashAPIKey(apiKey)).Take(&operator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if operator.Status != entity.OperatorStatusActive {
+		return nil, ErrOperatorDisabled
+	}
+	return &operator, nil
+}
+
+// CreateSession 建立或對應營運商玩家，並簽發短效 token 及遊戲啟動網址
+func (s *operatorService) CreateSession(operator *entity.Operator, request SessionRequest) (*Session, error) {
+	if request.ExternalPlayerID == "" {
+		return nil, ErrInvalidPlayerID
+	}
+	if err := s.CheckGame(operator.ID, request.GameID); err != nil {
+		return nil, err
+	}
+	if _, err := s.currencies.GetBetLimits(operator.ID, request.GameID, request.Currency); err != nil {
+		return nil, err
+	}
+
+	user, err := s.findOrCreatePlayer(operator, request)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == entity.UserStatusBlocked {
+		return nil, ErrUserBlocked
+	}
+
+	token, expiresAt, err := s.tokens.IssueSession(user, s.config.Operator.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	launchURL, err := s.launchURL(token, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		UserID:    user.ID,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		LaunchURL: launchURL,
+	}, nil
+}
+
+// GameSettings 返回營運商對遊戲的設定，沒有設定時遊戲開放且不使用品牌設定
+func (s *operatorService) GameSettings(operatorID int, gameID string) (*GameSettings, error) {
+	if _, ok := domain.GetSymbolCatalogue(gameID); !ok {
+		return nil, ErrGameNotFound
+	}
+
+	var record entity.OperatorGame
+	err := s.db.Where("operator_id = ? AND game_id = ?", operatorID, gameID).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &GameSettings{
+			OperatorID: operatorID,
+			GameID:     gameID,
+			Enabled:    true,
+			Branding:   map[string]string{},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &GameSettings{
+		OperatorID: record.OperatorID,
+		GameID:     record.GameID,
+		Enabled:    record.Enabled,
+	}
+	if err := json.Unmarshal([]byte(record.Branding), &settings.Branding); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetGameSettings 新增或更新營運商對遊戲的設定
+func (s *operatorService) SetGameSettings(settings GameSettings) error {
+	if _, ok := domain.GetSymbolCatalogue(settings.GameID); !ok {
+		return ErrGameNotFound
+	}
+	if _, err := s.GetOperator(settings.OperatorID); err != nil {
+		return err
+	}
+
+	branding := settings.Branding
+	if branding == nil {
+		branding = map[string]string{}
+	}
+	data, err := json.Marshal(branding)
+	if err != nil {
+		return err
+	}
+
+	record := &entity.OperatorGame{
+		UpdatedAt:  time.Now(),
+		OperatorID: settings.OperatorID,
+		GameID:     settings.GameID,
+		Enabled:    settings.Enabled,
+		Branding:   string(data),
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "operator_id"}, {Name: "game_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "enabled", "branding"}),
+	}).Create(record).Error
+}
+
+// CheckGame 檢查遊戲是否存在且對營運商開放
+func (s *operatorService) CheckGame(operatorID int, gameID string) error {
+	settings, err := s.GameSettings(operatorID, gameID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return ErrGameDisabled
+	}
+	return nil
+}
+
+// findOrCreatePlayer 查詢營運商玩家對應的使用者，不存在時建立
+func (s *operatorService) findOrCreatePlayer(operator *entity.Operator, request SessionRequest) (*entity.User, error) {
+	user, err := s.findPlayer(operator.ID, request.ExternalPlayerID)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	// 營運商玩家不以密碼登入，寫入隨機密碼的雜湊
+	password, err := bcrypt.GenerateFromPassword([]byte(utils.NewID()), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	name := request.Nickname
+	if name == "" {
+		name = operator.Code + "-" + request.ExternalPlayerID
+	}
+
+	user = &entity.User{
+		OperatorID: operator.ID,
+		Name:       truncateRunes(name, operatorPlayerNameLength),
+		Password:   string(password),
+		Role:       entity.RolePlayer,
+		Status:     entity.UserStatusActive,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.OperatorPlayer{
+			OperatorID: operator.ID,
+			ExternalID: request.ExternalPlayerID,
+			UserID:     user.ID,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// 同一玩家的並行請求已建立對應
+		return s.findPlayer(operator.ID, request.ExternalPlayerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *operatorService) findPlayer(operatorID int, externalID string) (*entity.User, error) {
+	var mapping entity.OperatorPlayer
+	if err := s.db.Where("operator_id = ? AND external_id = ?", operatorID, externalID).Take(&mapping).Error; err != nil {
+		return nil, err
+	}
+
+	// 對應的使用者已被刪除時不再建立新的使用者
+	var user entity.User
+	err := s.db.Where("operator_id = ?", operatorID).Take(&user, mapping.UserID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// launchURL 在遊戲前端網址附加 session 參數
+func (s *operatorService) launchURL(token string, request SessionRequest) (string, error) {
+	launch, err := url.Parse(s.config.Operator.LaunchURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := launch.Query()
+	query.Set("token", token)
+	query.Set("gameId", request.GameID)
+	query.Set("currency", string(request.Currency))
+	if request.Language != "" {
+		query.Set("lang", request.Language)
+	}
+	if request.LobbyURL != "" {
+		query.Set("lobbyUrl", request.LobbyURL)
+	}
+	launch.RawQuery = query.Encode()
+	return launch.String(), nil
+}
+
+// hashAPIKey 返回 API 金鑰的 SHA-256 雜湊
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/sms"
+	"passontw-slot-game/pkg/utils"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidPhone        = errors.New("phone is required")
+	ErrInvalidOTP          = errors.New("invalid or expired verification code")
+	ErrInvalidVerification = errors.New("invalid or expired verification token")
+)
+
+// otpCodeLength 驗證碼位數
+const otpCodeLength = 6
+
+// ThrottledError 請求過於頻繁，需等待 RetryAfter 後再試
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// OTPChallenge 已發送的驗證碼
+type OTPChallenge struct {
+	ExpiresAt time.Time
+	ResendAt  time.Time
+}
+
+// Verification 驗證碼通過後發出的 token，用於完成後續的操作
+type Verification struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// OTPService 以簡訊驗證碼確認使用者擁有該電話
+type OTPService interface {
+	Request(operatorID int, phone, purpose string) (*OTPChallenge, error)
+	Verify(operatorID int, phone, purpose, code string) (*Verification, error)
+	Consume(operatorID int, purpose, token string) (string, error)
+}
+
+type otpService struct {
+	db     *gorm.DB
+	config *config.Config
+	sender sms.Sender
+}
+
+func NewOTPService(db *gorm.DB, cfg *config.Config, sender sms.Sender) OTPService {
+	return &otpService{
+		db:     db,
+		config: cfg,
+		sender: sender,
+	}
+}
+
+// Request 產生驗證碼並以簡訊發送，先前發送的驗證碼隨即失效
+func (s *otpService) Request(operatorID int, phone, purpose string) (*OTPChallenge, error) {
+	if phone == "" {
+		return nil, ErrInvalidPhone
+	}
+	if err := s.checkThrottle(operatorID, phone, purpose); err != nil {
+		return nil, err
+	}
+	send, err := s.checkPurpose(operatorID, phone, purpose)
+	if err != nil {
+		return nil, err
+	}
+	if !send {
+		// 返回與實際發送相同的回應，避免藉此查詢電話是否已註冊
+		now := time.Now()
+		return &OTPChallenge{
+			ExpiresAt: now.Add(s.config.OTP.TTL),
+			ResendAt:  now.Add(s.config.OTP.ResendInterval),
+		}, nil
+	}
+
+	code, err := randomCode(otpCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &entity.PhoneVerification{
+		CreatedAt:  now,
+		OperatorID: operatorID,
+		Phone:      phone,
+		Purpose:    purpose,
+		CodeHash:   string(codeHash),
+		ExpiresAt:  now.Add(s.config.OTP.TTL),
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(s.config.OTP.TTL.Minutes()))
+	if err := s.sender.Send(phone, message); err != nil {
+		// 發送失敗的驗證碼不計入頻率限制
+		s.db.Delete(record)
+		return nil, err
+	}
+
+	return &OTPChallenge{
+		ExpiresAt: record.ExpiresAt,
+		ResendAt:  now.Add(s.config.OTP.ResendInterval),
+	}, nil
+}
+
+// Verify 檢查最新的驗證碼，錯誤次數達到上限後驗證碼失效
+func (s *otpService) Verify(operatorID int, phone, purpose, code string) (*Verification, error) {
+	var verification *Verification
+	var invalid bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record entity.PhoneVerification
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("operator_id = ? AND phone = ? AND purpose = ?", operatorID, phone, purpose).
+			Order("created_at DESC").
+			Take(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			invalid = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if record.VerifiedAt != nil || now.After(record.ExpiresAt) || record.Attempts >= s.config.OTP.MaxAttempts {
+			invalid = true
+			return nil
+		}
+
+		// 錯誤的嘗試需要寫入，因此不以返回錯誤的方式回滾交易
+		if bcrypt.CompareHashAndPassword([]byte(record.CodeHash), []byte(code)) != nil {
+			invalid = true
+			return tx.Model(&record).Update("attempts", gorm.Expr("attempts + 1")).Error
+		}
+
+		token := "vt_" + utils.NewID() + utils.NewID()
+		tokenHash := hashToken(token)
+		expiresAt := now.Add(s.config.OTP.VerificationTTL)
+		err = tx.Model(&record).Updates(map[string]interface{}{
+			"verified_at":      now,
+			"token_hash":       tokenHash,
+			"token_expires_at": expiresAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		verification = &Verification{
+			Token:     token,
+			ExpiresAt: expiresAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if invalid {
+		return nil, ErrInvalidOTP
+	}
+	return verification, nil
+}
+
+// Consume 使用驗證通過的 token 並返回其電話，每個 token 只能使用一次
+func (s *otpService) Consume(operatorID int, purpose, token string) (string, error) {
+	var phone string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record entity.PhoneVerification
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND operator_id = ? AND purpose = ?", hashToken(token), operatorID, purpose).
+			Take(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerification
+		}
+		if err != nil {
+			return err
+		}
+		if record.ConsumedAt != nil || record.TokenExpiresAt == nil || time.Now().After(*record.TokenExpiresAt) {
+			return ErrInvalidVerification
+		}
+
+		phone = record.Phone
+		return tx.Model(&record).Update("consumed_at", time.Now()).Error
+	})
+	return phone, err
+}
+
+// checkPurpose 檢查電話是否符合驗證用途，返回是否需要發送驗證碼
+// 註冊需要尚未註冊的電話；重設密碼時電話未註冊則不發送
+func (s *otpService) checkPurpose(operatorID int, phone, purpose string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&entity.User{}).Where("operator_id = ? AND phone = ?", operatorID, phone).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	switch purpose {
+	case entity.VerificationPurposeRegister:
+		if count > 0 {
+			return false, ErrPhoneRegistered
+		}
+		return true, nil
+	case entity.VerificationPurposeResetPassword:
+		return count > 0, nil
+	default:
+		return false, fmt.Errorf("unknown verification purpose: %s", purpose)
+	}
+}
+
+// checkThrottle 限制同一電話重新發送的間隔及每小時的發送次數
+func (s *otpService) checkThrottle(operatorID int, phone, purpose string) error {
+	now := time.Now()
+	var sent []entity.PhoneVerification
+	err := s.db.Select("created_at").
+		Where("operator_id = ? AND phone = ? AND purpose = ? AND created_at > ?", operatorID, phone, purpose, now.Add(-time.Hour)).
+		Order("created_at DESC").
+		Find(&sent).Error
+	if err != nil {
+		return err
+	}
+
+	if len(sent) > 0 {
+		if resendAt := sent[0].CreatedAt.Add(s.config.OTP.ResendInterval); now.Before(resendAt) {
+			return &ThrottledError{RetryAfter: resendAt.Sub(now)}
+		}
+	}
+	if limit := s.config.OTP.HourlyLimit; limit > 0 && len(sent) >= limit {
+		// 一小時內的發送次數降到上限以下時才能再發送
+		return &ThrottledError{RetryAfter: sent[limit-1].CreatedAt.Add(time.Hour).Sub(now)}
+	}
+	return nil
+}
+
+// randomCode 產生指定位數的數字驗證碼
+func randomCode(length int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", length, n), nil
+}
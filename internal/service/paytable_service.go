@@ -0,0 +1,336 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/domain/entity"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeRevisionTTL 快取目前生效修訂版的時間，排程的修訂版最多延遲此時間上線
+const activeRevisionTTL = 10 * time.Second
+
+var (
+	ErrRevisionNotFound   = errors.New("game revision not found")
+	ErrNoActiveRevision   = errors.New("no active game revision")
+	ErrNoPreviousRevision = errors.New("no previous game revision to roll back to")
+)
+
+// Revision 代表一個不可變的遊戲定義修訂版及其生成器與規則檢查器
+type Revision struct {
+	Hash       string
+	Definition domain.GameDefinition
+	Generator  *Generator
+	Checker    *Checker
+	Strips     [3][]domain.Symbol
+}
+
+// DefinitionFile 遊戲定義檔案格式，activateAt 為空時只登記修訂版而不排程上線
+type DefinitionFile struct {
+	ActivateAt *time.Time            `json:"activateAt"`
+	Definition domain.GameDefinition `json:"definition"`
+}
+
+type PaytableService interface {
+	Register(definition domain.GameDefinition) (*Revision, error)
+	Schedule(gameID, hash string, activateAt time.Time) error
+	Publish(tx *gorm.DB, definition domain.GameDefinition) (string, error)
+	Invalidate(gameID string)
+	Rollback(gameID string) (*Revision, error)
+	History(gameID string) ([]entity.GameRevisionActivation, error)
+	Active(gameID string) (*Revision, error)
+	Get(hash string) (*Revision, error)
+}
+
+type activeRevision struct {
+	hash      string
+	expiresAt time.Time
+}
+
+type paytableService struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	revisions map[string]*Revision       // 以雜湊快取已讀取的修訂版
+	active    map[string]*activeRevision // 以遊戲 ID 快取目前生效的修訂版
+}
+
+func NewPaytableService(db *gorm.DB, cfg *config.Config) (PaytableService, error) {
+	s := &paytableService{
+		db:        db,
+		revisions: make(map[string]*Revision),
+		active:    make(map[string]*activeRevision),
+	}
+
+	// 登記內建的遊戲定義，尚無任何排程時立即上線
+	revision, err := s.Register(domain.DefaultGameDefinition())
+	if err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := db.Model(&entity.GameRevisionActivation{}).Where("game_id = ?", domain.DefaultGameID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		if err := s.Schedule(domain.DefaultGameID, revision.Hash, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Game.DefinitionsDir != "" {
+		if err := s.loadDefinitions(cfg.Game.DefinitionsDir); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Register 儲存遊戲定義為不可變的修訂版，相同內容只會儲存一次
+func (s *paytableService) Register(definition domain.GameDefinition) (*Revision, error) {
+	hash, err := s.register(s.db, definition)
+	if err != nil {
+		return nil, err
+	}
+	return s.cache(hash, definition), nil
+}
+
+// register 寫入修訂版並返回雜湊，不更新快取，交易中呼叫時由呼叫方在提交後讀取
+func (s *paytableService) register(db *gorm.DB, definition domain.GameDefinition) (string, error) {
+	hash, err := definition.Hash()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return "", err
+	}
+
+	record := &entity.GameRevision{
+		Hash:       hash,
+		GameID:     definition.GameID,
+		Definition: string(data),
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error; err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// Schedule 排程修訂版於指定時間上線
+func (s *paytableService) Schedule(gameID, hash string, activateAt time.Time) error {
+	revision, err := s.Get(hash)
+	if err != nil {
+		return err
+	}
+	if revision.Definition.GameID != gameID {
+		return fmt.Errorf("revision %s does not belong to game %s", hash, gameID)
+	}
+
+	if err := s.schedule(s.db, gameID, hash, activateAt, false); err != nil {
+		return err
+	}
+	s.Invalidate(gameID)
+	return nil
+}
+
+func (s *paytableService) schedule(db *gorm.DB, gameID, hash string, activateAt time.Time, rollback bool) error {
+	activation := &entity.GameRevisionActivation{
+		GameID:       gameID,
+		RevisionHash: hash,
+		ActivateAt:   activateAt,
+		Rollback:     rollback,
+	}
+	return db.Create(activation).Error
+}
+
+// Publish 在交易中登記修訂版並立即上線，返回修訂版雜湊
+// 不更新任何快取，呼叫方須在交易提交後呼叫 Invalidate，避免交易回滾後快取留下未寫入的修訂版
+func (s *paytableService) Publish(tx *gorm.DB, definition domain.GameDefinition) (string, error) {
+	hash, err := s.register(tx, definition)
+	if err != nil {
+		return "", err
+	}
+	if err := s.schedule(tx, definition.GameID, hash, time.Now(), false); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// Invalidate 清除遊戲目前生效修訂版的快取，下次讀取時重新查詢上線排程
+func (s *paytableService) Invalidate(gameID string) {
+	s.mu.Lock()
+	delete(s.active, gameID)
+	s.mu.Unlock()
+}
+
+// Rollback 將遊戲恢復為目前生效修訂版之前的修訂版
+// 依已生效的上線紀錄重建修訂版堆疊，一般上線推入、回溯取出，連續回溯會逐步回到更早的修訂版
+func (s *paytableService) Rollback(gameID string) (*Revision, error) {
+	var activations []entity.GameRevisionActivation
+	err := s.db.Where("game_id = ? AND activate_at <= ?", gameID, time.Now()).
+		Order("activate_at, id").
+		Find(&activations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []string
+	for _, activation := range activations {
+		switch {
+		case activation.Rollback:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case len(stack) == 0 || stack[len(stack)-1] != activation.RevisionHash:
+			stack = append(stack, activation.RevisionHash)
+		}
+	}
+	if len(stack) < 2 {
+		return nil, ErrNoPreviousRevision
+	}
+
+	previous := stack[len(stack)-2]
+	if err := s.schedule(s.db, gameID, previous, time.Now(), true); err != nil {
+		return nil, err
+	}
+	s.Invalidate(gameID)
+	return s.Get(previous)
+}
+
+// History 返回遊戲所有的上線排程，最新的在前
+func (s *paytableService) History(gameID string) ([]entity.GameRevisionActivation, error) {
+	var activations []entity.GameRevisionActivation
+	err := s.db.Where("game_id = ?", gameID).Order("activate_at DESC, id DESC").Find(&activations).Error
+	return activations, err
+}
+
+// Active 返回目前生效的修訂版，即上線時間最晚且不晚於現在的排程
+func (s *paytableService) Active(gameID string) (*Revision, error) {
+	s.mu.Lock()
+	cached, ok := s.active[gameID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return s.Get(cached.hash)
+	}
+
+	var activation entity.GameRevisionActivation
+	err := s.db.Where("game_id = ? AND activate_at <= ?", gameID, time.Now()).
+		Order("activate_at DESC, id DESC").
+		First(&activation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoActiveRevision
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.active[gameID] = &activeRevision{
+		hash:      activation.RevisionHash,
+		expiresAt: time.Now().Add(activeRevisionTTL),
+	}
+	s.mu.Unlock()
+
+	return s.Get(activation.RevisionHash)
+}
+
+// Get 根據雜湊讀取修訂版
+func (s *paytableService) Get(hash string) (*Revision, error) {
+	s.mu.Lock()
+	revision, ok := s.revisions[hash]
+	s.mu.Unlock()
+	if ok {
+		return revision, nil
+	}
+
+	var record entity.GameRevision
+	if err := s.db.Where("hash = ?", hash).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+
+	var definition domain.GameDefinition
+	if err := json.Unmarshal([]byte(record.Definition), &definition); err != nil {
+		return nil, err
+	}
+	return s.cache(hash, definition), nil
+}
+
+func (s *paytableService) cache(hash string, definition domain.GameDefinition) *Revision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if revision, ok := s.revisions[hash]; ok {
+		return revision
+	}
+	revision := &Revision{
+		Hash:       hash,
+		Definition: definition,
+		Generator:  NewGeneratorFromDefinition(definition),
+		Checker:    NewCheckerFromDefinition(definition),
+		Strips:     domain.BuildReelStrips(definition.Symbols),
+	}
+	s.revisions[hash] = revision
+	return revision
+}
+
+// loadDefinitions 驗證並登記目錄中的遊戲定義檔案，並排程尚未排程的上線時間
+// 任一檔案無法通過驗證時返回錯誤，讓服務啟動失敗而不是上線無法運作的定義
+func (s *paytableService) loadDefinitions(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var definitionFile DefinitionFile
+		if err := json.Unmarshal(data, &definitionFile); err != nil {
+			return fmt.Errorf("invalid game definition %s: %w", file, err)
+		}
+		if errs := definitionFile.Definition.Validate(); len(errs) > 0 {
+			return fmt.Errorf("invalid game definition %s: %s", file, strings.Join(errs, "; "))
+		}
+
+		revision, err := s.Register(definitionFile.Definition)
+		if err != nil {
+			return err
+		}
+		log.Printf("Loaded game definition %s as revision %s", file, revision.Hash)
+
+		if definitionFile.ActivateAt == nil {
+			continue
+		}
+		var count int64
+		err = s.db.Model(&entity.GameRevisionActivation{}).
+			Where("revision_hash = ? AND activate_at = ?", revision.Hash, *definitionFile.ActivateAt).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := s.Schedule(revision.Definition.GameID, revision.Hash, *definitionFile.ActivateAt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/pkg/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CurrencySummary 單一貨幣在期間內已完成遊戲局的彙總
+type CurrencySummary struct {
+	Currency money.Currency
+	Rounds   int64
+	Bet      money.Money
+	Win      money.Money
+	GGR      money.Money // 營收，下注減去派彩
+	BaseBet  money.Money // 以基準貨幣換算，缺少匯率時為零值
+	BaseWin  money.Money
+	BaseGGR  money.Money
+	HasRate  bool
+}
+
+// Summary 期間內所有貨幣的彙總，Total 只包含有匯率的貨幣
+type Summary struct {
+	From         time.Time
+	To           time.Time
+	BaseCurrency money.Currency
+	Currencies   []CurrencySummary
+	TotalBet     money.Money
+	TotalWin     money.Money
+	TotalGGR     money.Money
+}
+
+type ReportService interface {
+	Summary(operatorID int, from, to time.Time) (*Summary, error)
+}
+
+type reportService struct {
+	db         *gorm.DB
+	currencies CurrencyService
+}
+
+func NewReportService(db *gorm.DB, currencies CurrencyService) ReportService {
+	return &reportService{
+		db:         db,
+		currencies: currencies,
+	}
+}
+
+// Summary 依貨幣彙總營運商期間內完成的遊戲局，並以匯率表換算為基準貨幣
+func (s *reportService) Summary(operatorID int, from, to time.Time) (*Summary, error) {
+	var rows []struct {
+		Currency string
+		Rounds   int64
+		Bet      int64
+		Win      int64
+	}
+	err := s.db.Model(&entity.GameRound{}).
+		Select("currency, count(*) AS rounds, coalesce(sum(bet_amount), 0) AS bet, coalesce(sum(total_win), 0) AS win").
+		Where("operator_id = ? AND status = ? AND completed_at >= ? AND completed_at < ?", operatorID, RoundCompleted, from, to).
+		Group("currency").
+		Order("currency").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	base := s.currencies.BaseCurrency()
+	summary := &Summary{
+		From:         from,
+		To:           to,
+		BaseCurrency: base,
+		Currencies:   make([]CurrencySummary, 0, len(rows)),
+		TotalBet:     money.Zero(base),
+		TotalWin:     money.Zero(base),
+		TotalGGR:     money.Zero(base),
+	}
+
+	for _, row := range rows {
+		currency := money.Currency(row.Currency)
+		item := CurrencySummary{
+			Currency: currency,
+			Rounds:   row.Rounds,
+			Bet:      money.New(row.Bet, currency),
+			Win:      money.New(row.Win, currency),
+		}
+		item.GGR = item.Bet.Sub(item.Win)
+
+		baseBet, err := s.currencies.ToBase(item.Bet)
+		if errors.Is(err, ErrExchangeRateNotFound) {
+			summary.Currencies = append(summary.Currencies, item)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		baseWin, err := s.currencies.ToBase(item.Win)
+		if err != nil {
+			return nil, err
+		}
+
+		item.HasRate = true
+		item.BaseBet = baseBet
+		item.BaseWin = baseWin
+		item.BaseGGR = baseBet.Sub(baseWin)
+		summary.TotalBet = summary.TotalBet.Add(baseBet)
+		summary.TotalWin = summary.TotalWin.Add(baseWin)
+		summary.TotalGGR = summary.TotalGGR.Add(item.BaseGGR)
+		summary.Currencies = append(summary.Currencies, item)
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"passontw-slot-game/internal/domain"
+	"passontw-slot-game/internal/domain/models"
+	"passontw-slot-game/pkg/money"
+	"time"
+)
+
+// RoundStatus 代表遊戲局的狀態
+type RoundStatus string
+
+const (
+	RoundOpen      RoundStatus = "open"
+	RoundCompleted RoundStatus = "completed"
+)
+
+// RoundFeature 代表遊戲局中尚未完成的特色玩法
+type RoundFeature string
+
+const (
+	FeatureNone        RoundFeature = ""
+	FeatureFreeSpins   RoundFeature = "free_spins"
+	FeatureHoldAndSpin RoundFeature = "hold_and_spin"
+	FeatureNudgeHold   RoundFeature = "nudge_hold"
+)
+
+// 遊戲局步驟類型
+const (
+	EventSpin     = "spin"
+	EventFreeSpin = "free_spin"
+	EventRespin   = "respin"
+	EventHold     = "hold"
+	EventNudge    = "nudge"
+)
+
+// RoundEvent 代表遊戲局中的一個步驟，例如主遊戲旋轉或一次免費旋轉
+type RoundEvent struct {
+	Seq       int          `json:"seq"`
+	Type      string       `json:"type"`
+	Board     models.Board `json:"board"`
+	Win       WinResult    `json:"win"`
+	WinAmount money.Money  `json:"winAmount"`
+	Coins     []CoinWin    `json:"coins,omitempty"`
+	Balance   *money.Money `json:"balance,omitempty"` // 此步驟扣款或派彩後的錢包餘額，派彩完成前為空
+
+	MaxWinReached bool `json:"maxWinReached,omitempty"`
+}
+
+// CoinWin 代表盤面上鎖定的一枚金幣
+type CoinWin struct {
+	Row     int            `json:"row"`
+	Col     int            `json:"col"`
+	Value   float64        `json:"value"`  // 獎金倍數（以下注金額計）
+	Amount  money.Money    `json:"amount"` // 金幣獎金
+	Jackpot domain.Jackpot `json:"jackpot,omitempty"`
+}
+
+// FruitOffer 代表水果機模式中未中獎後給予玩家的選項
+type FruitOffer struct {
+	Hold   bool `json:"hold"`   // 可鎖定部分輪軸後重轉
+	Nudges int  `json:"nudges"` // 可將輪軸沿輪帶移動一格的次數
+}
+
+// Round 代表一次下注及其觸發的所有特色玩法
+type Round struct {
+	ID            string                 `json:"id"`
+	OperatorID    int                    `json:"operatorId"`
+	UserID        int                    `json:"userId"`
+	RevisionHash  string                 `json:"revisionHash"` // 產生此局結果的遊戲定義修訂版
+	BetAmount     money.Money            `json:"betAmount"`
+	MaxWin        *money.Money           `json:"maxWin,omitempty"` // 開局時凍結的單局最高獎金，空值代表不限制
+	Status        RoundStatus            `json:"status"`
+	Feature       RoundFeature           `json:"feature"`
+	Modifiers     []domain.WildModifier  `json:"modifiers"`
+	Overlay       models.Overlay         `json:"overlay"`
+	FreeSpinsLeft int                    `json:"freeSpinsLeft"`
+	Coins         models.Overlay         `json:"coins"`
+	CoinValues    [3][3]domain.CoinValue `json:"coinValues"`
+	RespinsLeft   int                    `json:"respinsLeft"`
+	Stops         [3]int                 `json:"stops"`
+	Offer         *FruitOffer            `json:"offer,omitempty"`
+	TotalWin      money.Money            `json:"totalWin"`
+	JackpotWin    money.Money            `json:"jackpotWin"`
+	Paid          money.Money            `json:"paid"` // 已派發至錢包的獎金
+	MaxWinReached bool                   `json:"maxWinReached"`
+	AutoCompleted bool                   `json:"autoCompleted,omitempty"` // 玩家中斷後由系統自動結算
+	ForceClosed   bool                   `json:"forceClosed,omitempty"`   // 由管理者強制結算
+	Events        []RoundEvent           `json:"events"`
+	CreatedAt     time.Time              `json:"createdAt"`
+	UpdatedAt     time.Time              `json:"updatedAt"`
+}
+
+// SpinResult 代表一次旋轉的結果及所屬遊戲局
+type SpinResult struct {
+	Round    *Round      `json:"round"`
+	Event    RoundEvent  `json:"event"`
+	Balance  money.Money `json:"balance"` // 此步驟結算後的錢包餘額
+	Replayed bool        `json:"-"`       // 是否為重複請求所返回的原始結果
+}
+
+// HasModifier 檢查遊戲局是否啟用指定的百搭效果
+func (r *Round) HasModifier(modifier domain.WildModifier) bool {
+	for _, m := range r.Modifiers {
+		if m == modifier {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedCoins 返回目前鎖定的所有金幣
+func (r *Round) lockedCoins() []CoinWin {
+	var coins []CoinWin
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if r.Coins.Locked[i][j] {
+				coins = append(coins, CoinWin{
+					Row:     i,
+					Col:     j,
+					Value:   r.CoinValues[i][j].Value,
+					Amount:  r.BetAmount.Mul(r.CoinValues[i][j].Value, money.RoundDown),
+					Jackpot: r.CoinValues[i][j].Jackpot,
+				})
+			}
+		}
+	}
+	return coins
+}
+
+// lastEvent 返回遊戲局最近一次的步驟
+func (r *Round) lastEvent() RoundEvent {
+	return r.Events[len(r.Events)-1]
+}
+
+// unpaidEvents 返回尚未派發獎金的中獎步驟，已派彩金額為依序累加的步驟獎金
+func (r *Round) unpaidEvents() []RoundEvent {
+	paid := money.Zero(r.BetAmount.Currency())
+	for i, event := range r.Events {
+		if paid.Cmp(r.Paid) >= 0 {
+			var unpaid []RoundEvent
+			for _, event := range r.Events[i:] {
+				if event.WinAmount.IsPositive() {
+					unpaid = append(unpaid, event)
+				}
+			}
+			return unpaid
+		}
+		paid = paid.Add(event.WinAmount)
+	}
+	return nil
+}
+
+// recordBalances 記錄各步驟結算後的錢包餘額，credited 為本次派彩各步驟派彩後的餘額
+// 沒有派彩的步驟不異動錢包：在本次最後一筆派彩之後的記錄目前餘額 current，之前的沿用前一步驟的餘額；
+// 返回是否有步驟的餘額被更新
+func (r *Round) recordBalances(credited map[int]money.Money, current money.Money) bool {
+	lastCredited := 0
+	for seq := range credited {
+		if seq > lastCredited {
+			lastCredited = seq
+		}
+	}
+
+	changed := false
+	var previous *money.Money
+	for i := range r.Events {
+		event := &r.Events[i]
+		if balance, ok := credited[event.Seq]; ok {
+			event.Balance = &balance
+			changed = true
+		} else if event.Balance == nil {
+			balance := current
+			if previous != nil && event.Seq < lastCredited {
+				balance = *previous
+			}
+			event.Balance = &balance
+			changed = true
+		}
+		previous = event.Balance
+	}
+	return changed
+}
+
+// capWin 從事件獎金扣除超過上限的部分，並依序從中獎線及金幣的獎金扣除，使明細加總與事件獎金一致
+// 扣除金額不超過事件獎金，獎池金幣最後才扣除；返回實際扣除的金額及其中從獎池扣除的金額
+func (e *RoundEvent) capWin(excess money.Money) (money.Money, money.Money) {
+	if excess.Cmp(e.WinAmount) > 0 {
+		excess = e.WinAmount
+	}
+	cut := excess
+	e.WinAmount = e.WinAmount.Sub(excess)
+	e.Win.Payout = e.Win.Payout.Sub(excess)
+	for i := len(e.Win.Lines) - 1; i >= 0 && excess.IsPositive(); i-- {
+		excess = deduct(&e.Win.Lines[i].Payout, excess)
+	}
+	for i := len(e.Coins) - 1; i >= 0 && excess.IsPositive(); i-- {
+		if e.Coins[i].Jackpot == domain.JackpotNone {
+			excess = deduct(&e.Coins[i].Amount, excess)
+		}
+	}
+
+	jackpotCut := money.Zero(excess.Currency())
+	for i := len(e.Coins) - 1; i >= 0 && excess.IsPositive(); i-- {
+		if e.Coins[i].Jackpot != domain.JackpotNone {
+			remaining := deduct(&e.Coins[i].Amount, excess)
+			jackpotCut = jackpotCut.Add(excess.Sub(remaining))
+			excess = remaining
+		}
+	}
+	return cut, jackpotCut
+}
+
+// deduct 從金額扣除最多 excess，返回尚未扣除的部分
+func deduct(amount *money.Money, excess money.Money) money.Money {
+	cut := excess
+	if amount.Cmp(cut) < 0 {
+		cut = *amount
+	}
+	*amount = amount.Sub(cut)
+	return excess.Sub(cut)
+}
+
+// addEvent 記錄遊戲局步驟並累加獎金
+func (r *Round) addEvent(eventType string, board models.Board, win WinResult, coins []CoinWin) {
+	event := RoundEvent{
+		Seq:       len(r.Events) + 1,
+		Type:      eventType,
+		Board:     board,
+		Win:       win,
+		WinAmount: win.Payout,
+		Coins:     coins,
+	}
+	r.Events = append(r.Events, event)
+	r.TotalWin = r.TotalWin.Add(event.WinAmount)
+	r.UpdatedAt = time.Now()
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"passontw-slot-game/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// saveRound 將遊戲局狀態寫入資料庫
+func saveRound(db *gorm.DB, round *Round) error {
+	state, err := json.Marshal(round)
+	if err != nil {
+		return err
+	}
+
+	record := &entity.GameRound{
+		ID:           round.ID,
+		CreatedAt:    round.CreatedAt,
+		UpdatedAt:    round.UpdatedAt,
+		OperatorID:   round.OperatorID,
+		UserID:       round.UserID,
+		RevisionHash: round.RevisionHash,
+		Status:       string(round.Status),
+		Feature:      string(round.Feature),
+		Currency:     string(round.BetAmount.Currency()),
+		BetAmount:    round.BetAmount.Minor(),
+		TotalWin:     round.TotalWin.Minor(),
+		Paid:         round.Paid.Minor(),
+		State:        string(state),
+	}
+	if round.Status == RoundCompleted {
+		completedAt := round.UpdatedAt
+		record.CompletedAt = &completedAt
+	}
+
+	return db.Save(record).Error
+}
+
+// loadRound 從資料庫讀取遊戲局狀態
+func loadRound(db *gorm.DB, roundID string) (*Round, error) {
+	var record entity.GameRound
+	if err := db.Where("id = ?", roundID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoundNotFound
+		}
+		return nil, err
+	}
+	return decodeRound(record)
+}
+
+// lockRound 在交易中讀取並鎖定遊戲局 (SELECT ... FOR UPDATE)，同一遊戲局的步驟依序處理
+func lockRound(tx *gorm.DB, roundID string) (*Round, error) {
+	return loadRound(tx.Clauses(clause.Locking{Strength: "UPDATE"}), roundID)
+}
+
+// findRounds 查詢符合條件的遊戲局，依建立時間排序
+func findRounds(query *gorm.DB) ([]*Round, error) {
+	var records []entity.GameRound
+	if err := query.Order("created_at").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	rounds := make([]*Round, 0, len(records))
+	for _, record := range records {
+		round, err := decodeRound(record)
+		if err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, round)
+	}
+	return rounds, nil
+}
+
+// findAbandonedRounds 查詢超過指定時間未有動作的進行中遊戲局
+func findAbandonedRounds(db *gorm.DB, before time.Time) ([]*Round, error) {
+	return findRounds(db.Where("status = ? AND updated_at < ?", RoundOpen, before))
+}
+
+// findUnpaidRounds 查詢獎金尚未全部派發且在指定時間之前更新的遊戲局
+func findUnpaidRounds(db *gorm.DB, before time.Time) ([]*Round, error) {
+	return findRounds(db.Where("paid < total_win AND updated_at < ?", before))
+}
+
+func decodeRound(record entity.GameRound) (*Round, error) {
+	var round Round
+	if err := json.Unmarshal([]byte(record.State), &round); err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
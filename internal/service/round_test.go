@@ -0,0 +1,106 @@
+package service
+
+import (
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/models"
+	"passontw-slot-game/pkg/money"
+	"testing"
+)
+
+func TestRecordBalances(t *testing.T) {
+	m := func(value string) money.Money { return money.MustParse(value, "TWD") }
+	debited := m("99")
+	round := &Round{
+		BetAmount: m("1"),
+		Events: []RoundEvent{
+			{Seq: 1, WinAmount: m("0"), Balance: &debited},
+			{Seq: 2, WinAmount: m("0")},
+			{Seq: 3, WinAmount: m("5")},
+			{Seq: 4, WinAmount: m("0")},
+		},
+	}
+
+	if !round.recordBalances(map[int]money.Money{3: m("104")}, m("104")) {
+		t.Fatal("recordBalances reported no change")
+	}
+	want := []string{"99.00", "99.00", "104.00", "104.00"}
+	for i, event := range round.Events {
+		if event.Balance == nil || event.Balance.String() != want[i] {
+			t.Errorf("event %d balance = %v, want %s", event.Seq, event.Balance, want[i])
+		}
+	}
+
+	if round.recordBalances(nil, m("50")) {
+		t.Error("recordBalances changed balances that were already recorded")
+	}
+}
+
+func TestCapWin(t *testing.T) {
+	m := func(value string) money.Money { return money.MustParse(value, "TWD") }
+	tests := []struct {
+		name      string
+		excess    money.Money
+		wantCut   string
+		wantWin   string
+		wantLines []string
+	}{
+		{"within the step win", m("4"), "4.00", "2.00", []string{"2.00", "0.00"}},
+		{"exceeds the step win", m("9"), "6.00", "0.00", []string{"0.00", "0.00"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := RoundEvent{
+				WinAmount: m("6"),
+				Win: WinResult{
+					Payout: m("6"),
+					Lines:  []models.WinningLine{{Payout: m("2")}, {Payout: m("4")}},
+				},
+			}
+			cut, jackpotCut := event.capWin(tt.excess)
+			if cut.String() != tt.wantCut || !jackpotCut.IsZero() {
+				t.Errorf("capWin(%s) = %s, %s, want %s, 0.00", tt.excess, cut, jackpotCut, tt.wantCut)
+			}
+			if event.WinAmount.String() != tt.wantWin || event.Win.Payout.String() != tt.wantWin {
+				t.Errorf("win = %s, payout = %s, want %s", event.WinAmount, event.Win.Payout, tt.wantWin)
+			}
+			for i, line := range event.Win.Lines {
+				if line.Payout.String() != tt.wantLines[i] {
+					t.Errorf("line %d payout = %s, want %s", i, line.Payout, tt.wantLines[i])
+				}
+			}
+		})
+	}
+}
+
+// TestApplyWinCapExcessAboveLastStep 超過上限的部分大於最後一個步驟的獎金時，只扣除該步驟的獎金而不會成為負數
+func TestApplyWinCapExcessAboveLastStep(t *testing.T) {
+	m := func(value string) money.Money { return money.MustParse(value, "TWD") }
+	limit := m("10")
+	round := &Round{
+		BetAmount:  m("1"),
+		MaxWin:     &limit,
+		TotalWin:   m("15"),
+		JackpotWin: m("0"),
+		Events: []RoundEvent{
+			{Seq: 1, WinAmount: m("12"), Win: WinResult{Payout: m("12")}},
+			{Seq: 2, WinAmount: m("3"), Win: WinResult{Payout: m("3")}},
+		},
+		FreeSpinsLeft: 5,
+	}
+
+	s := &gameService{config: &config.Config{}}
+	s.applyWinCap(round)
+
+	last := round.lastEvent()
+	if last.WinAmount.String() != "0.00" || last.Win.Payout.String() != "0.00" {
+		t.Errorf("last step win = %s, payout = %s, want 0.00", last.WinAmount, last.Win.Payout)
+	}
+	if round.TotalWin.String() != "12.00" {
+		t.Errorf("total win = %s, want 12.00", round.TotalWin)
+	}
+	if !round.MaxWinReached || !last.MaxWinReached || round.FreeSpinsLeft != 0 {
+		t.Errorf("round not ended at the max win: reached %v, step reached %v, free spins %d",
+			round.MaxWinReached, last.MaxWinReached, round.FreeSpinsLeft)
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/seamless"
+	"passontw-slot-game/pkg/money"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seamlessRetryDelay 第一次重試前的等待時間，之後每次加倍
+const seamlessRetryDelay = 200 * time.Millisecond
+
+// operatorError 營運商返回的錯誤回應
+type operatorError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *operatorError) Error() string {
+	return fmt.Sprintf("operator wallet error %d %s: %s", e.status, e.code, e.msg)
+}
+
+// walletPlayer 使用者所屬營運商的錢包 API 及傳給營運商的玩家識別碼
+type walletPlayer struct {
+	id     string
+	url    string
+	secret string
+}
+
+// seamlessWallet 透過營運商錢包 API 扣款及派彩，餘額由營運商保存
+// 每位使用者的請求送往所屬營運商設定的錢包網址，並以該營運商的密鑰簽章
+type seamlessWallet struct {
+	db      *gorm.DB
+	client  *http.Client
+	retries int
+}
+
+func NewSeamlessWallet(db *gorm.DB, cfg *config.Config) WalletService {
+	return &seamlessWallet{
+		db:      db,
+		client:  &http.Client{Timeout: cfg.Wallet.Timeout},
+		retries: cfg.Wallet.Retries,
+	}
+}
+
+// Balances 向營運商查詢使用者所有貨幣的餘額
+func (s *seamlessWallet) Balances(userID int) ([]money.Money, error) {
+	player, err := s.player(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.balances(player, seamless.BalanceRequest{PlayerID: player.id})
+}
+
+// Balance 向營運商查詢使用者指定貨幣的餘額
+func (s *seamlessWallet) Balance(userID int, currency money.Currency) (money.Money, error) {
+	player, err := s.player(userID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	balances, err := s.balances(player, seamless.BalanceRequest{PlayerID: player.id, Currency: string(currency)})
+	if err != nil {
+		return money.Money{}, err
+	}
+	for _, balance := range balances {
+		if balance.Currency() == currency {
+			return balance, nil
+		}
+	}
+	return money.Zero(currency), nil
+}
+
+// Debit 呼叫營運商扣款，tx 不適用於外部錢包
+func (s *seamlessWallet) Debit(_ *gorm.DB, transfer Transfer) (money.Money, error) {
+	if !transfer.Amount.IsPositive() {
+		return money.Money{}, ErrInvalidAmount
+	}
+	return s.transaction(seamless.PathDebit, transfer)
+}
+
+// Credit 呼叫營運商派彩，金額為 0 時只查詢餘額
+func (s *seamlessWallet) Credit(_ *gorm.DB, transfer Transfer) (money.Money, error) {
+	if transfer.Amount.IsNegative() {
+		return money.Money{}, ErrInvalidAmount
+	}
+	if transfer.Amount.IsZero() {
+		return s.Balance(transfer.UserID, transfer.Amount.Currency())
+	}
+	return s.transaction(seamless.PathCredit, transfer)
+}
+
+// Rollback 呼叫營運商撤銷扣款，營運商沒有該筆扣款時視為成功
+func (s *seamlessWallet) Rollback(transfer Transfer) error {
+	_, err := s.transaction(seamless.PathRollback, transfer)
+	var opErr *operatorError
+	if errors.As(err, &opErr) && opErr.code == seamless.CodeTransactionNotFound {
+		return nil
+	}
+	return err
+}
+
+// Deposit 外部錢包的入帳由營運商處理
+func (s *seamlessWallet) Deposit(int, int, int, money.Money, string) (money.Money, error) {
+	return money.Money{}, ErrOperatorManaged
+}
+
+// Adjust 外部錢包的餘額調整由營運商處理
+func (s *seamlessWallet) Adjust(int, int, int, money.Money, string) (money.Money, error) {
+	return money.Money{}, ErrOperatorManaged
+}
+
+func (s *seamlessWallet) transaction(path string, transfer Transfer) (money.Money, error) {
+	player, err := s.player(transfer.UserID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	request := seamless.TransactionRequest{
+		TransactionID: transfer.TransactionID,
+		PlayerID:      player.id,
+		RoundID:       transfer.RoundID,
+		Currency:      string(transfer.Amount.Currency()),
+		Amount:        transfer.Amount.String(),
+	}
+
+	var response seamless.BalanceResponse
+	if err := s.call(player, path, request, &response); err != nil {
+		var opErr *operatorError
+		if errors.As(err, &opErr) && opErr.code == seamless.CodeInsufficientFunds {
+			return money.Money{}, ErrInsufficientFunds
+		}
+		return money.Money{}, err
+	}
+
+	balances, err := decodeBalances(response)
+	if err != nil {
+		return money.Money{}, err
+	}
+	for _, balance := range balances {
+		if balance.Currency() == transfer.Amount.Currency() {
+			return balance, nil
+		}
+	}
+	return money.Money{}, fmt.Errorf("%w: operator returned no %s balance", ErrWalletUnavailable, transfer.Amount.Currency())
+}
+
+func (s *seamlessWallet) balances(player *walletPlayer, request seamless.BalanceRequest) ([]money.Money, error) {
+	var response seamless.BalanceResponse
+	if err := s.call(player, seamless.PathBalance, request, &response); err != nil {
+		return nil, err
+	}
+	return decodeBalances(response)
+}
+
+// call 發送簽章的請求，網路錯誤及 5xx 回應會以相同內容重試，由交易 ID 保證冪等
+func (s *seamlessWallet) call(player *walletPlayer, path string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	delay := seamlessRetryDelay
+	for attempt := 0; ; attempt++ {
+		err = s.send(player, path, body, response)
+		var opErr *operatorError
+		retryable := err != nil && (!errors.As(err, &opErr) || opErr.status >= http.StatusInternalServerError)
+		if !retryable || attempt >= s.retries {
+			break
+		}
+
+		log.Printf("Operator wallet %s failed (attempt %d): %v", path, attempt+1, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	var opErr *operatorError
+	if err != nil && !errors.As(err, &opErr) {
+		return fmt.Errorf("%w: %v", ErrWalletUnavailable, err)
+	}
+	return err
+}
+
+func (s *seamlessWallet) send(player *walletPlayer, path string, body []byte, response interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, player.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(seamless.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(seamless.HeaderSignature, seamless.Sign(player.secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp seamless.ErrorResponse
+		_ = json.Unmarshal(data, &errResp)
+		return &operatorError{status: resp.StatusCode, code: errResp.Code, msg: errResp.Message}
+	}
+	return json.Unmarshal(data, response)
+}
+
+func decodeBalances(response seamless.BalanceResponse) ([]money.Money, error) {
+	balances := make([]money.Money, 0, len(response.Balances))
+	for _, balance := range response.Balances {
+		amount, err := money.Parse(balance.Amount, money.Currency(balance.Currency))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid operator balance: %v", ErrWalletUnavailable, err)
+		}
+		balances = append(balances, amount)
+	}
+	return balances, nil
+}
+
+// player 查詢使用者所屬營運商的錢包設定及傳給營運商的玩家識別碼
+// 經由營運商建立的玩家使用營運商的外部 ID，其他使用者使用本服務的使用者 ID
+func (s *seamlessWallet) player(userID int) (*walletPlayer, error) {
+	var user entity.User
+	if err := s.db.Unscoped().Select("id", "operator_id").Take(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	var operator entity.Operator
+	if err := s.db.Select("id", "wallet_url", "wallet_secret").Take(&operator, user.OperatorID).Error; err != nil {
+		return nil, err
+	}
+	if operator.WalletURL == "" || operator.WalletSecret == "" {
+		return nil, fmt.Errorf("%w: wallet of operator %d is not configured", ErrWalletUnavailable, operator.ID)
+	}
+
+	player := &walletPlayer{
+		id:     strconv.Itoa(userID),
+		url:    operator.WalletURL,
+		secret: operator.WalletSecret,
+	}
+	var mapping entity.OperatorPlayer
+	err := s.db.Where("user_id = ?", userID).Take(&mapping).Error
+	if err == nil {
+		player.id = mapping.ExternalID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return player, nil
+}
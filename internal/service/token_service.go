@@ -0,0 +1,297 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/token"
+	"passontw-slot-game/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// revokedTokenCleanupInterval 清除已過期撤銷紀錄的間隔
+const revokedTokenCleanupInterval = time.Hour
+
+// TokenPair 登入或更新後發出的 token
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// TokenService 統一簽發及驗證 token
+// access token 為短效 JWT，refresh token 為保存在伺服器端的隨機字串，每次使用後輪替
+type TokenService interface {
+	Issue(user *entity.User) (*TokenPair, error)
+	IssueSession(user *entity.User, ttl time.Duration) (string, time.Time, error)
+	IssueChallenge(user *entity.User, scope string) (string, time.Time, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	Revoke(claims *token.Claims, refreshToken string) error
+	RevokeUser(userID int) error
+	ValidateAccessToken(tokenString string) (*token.Claims, error)
+	ValidateChallenge(tokenString, scope string) (*token.Claims, error)
+	JWKS() token.JWKS
+}
+
+type tokenService struct {
+	db     *gorm.DB
+	config *config.Config
+	keys   *token.KeySet
+}
+
+func NewTokenService(db *gorm.DB, cfg *config.Config) (TokenService, error) {
+	keys, err := loadKeySet(cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Signing tokens with key %s", keys.SigningKeyID())
+
+	s := &tokenService{
+		db:     db,
+		config: cfg,
+		keys:   keys,
+	}
+	go s.cleanupRevokedTokens()
+	return s, nil
+}
+
+// loadKeySet 載入設定的金鑰，未設定私鑰時使用暫時金鑰
+func loadKeySet(cfg config.JWTConfig) (*token.KeySet, error) {
+	if cfg.SigningKeyFile == "" {
+		return token.GenerateKeySet()
+	}
+	return token.LoadKeySet(cfg.SigningKeyFile, cfg.VerifyKeyFiles)
+}
+
+// Issue 為使用者簽發 access token 並開始新的 refresh token family
+func (s *tokenService) Issue(user *entity.User) (*TokenPair, error) {
+	var pair *TokenPair
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		pair, err = s.issuePair(tx, user, utils.NewID())
+		return err
+	})
+	return pair, err
+}
+
+// IssueSession 簽發營運商玩家使用的短效 access token，不附帶 refresh token
+func (s *tokenService) IssueSession(user *entity.User, ttl time.Duration) (string, time.Time, error) {
+	return s.signToken(user, "", ttl)
+}
+
+// IssueChallenge 簽發只能用於完成兩步驟驗證的短效 token，不帶任何權限
+func (s *tokenService) IssueChallenge(user *entity.User, scope string) (string, time.Time, error) {
+	return s.signToken(user, scope, s.config.TwoFactor.ChallengeTTL)
+}
+
+// Refresh 以 refresh token 換發新的 token，舊的 refresh token 隨即失效
+// 已撤銷的 refresh token 再被使用代表可能外洩，整個 family 一併撤銷
+func (s *tokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	var pair *TokenPair
+	reused := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var record entity.RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(refreshToken)).
+			Take(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		if record.RevokedAt != nil {
+			reused = true
+			return s.revokeFamily(tx, record.FamilyID)
+		}
+		if time.Now().After(record.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		// 重新讀取使用者，角色、權限及狀態的變更在換發時生效
+		var user entity.User
+		err = tx.Take(&user, record.UserID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 使用者已被刪除
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		if user.Status == entity.UserStatusBlocked {
+			return ErrUserBlocked
+		}
+		if user.RequiresTwoFactor() && !user.TwoFactorEnabled() {
+			return ErrTwoFactorRequired
+		}
+
+		now := time.Now()
+		if err := tx.Model(&record).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		pair, err = s.issuePair(tx, &user, record.FamilyID)
+		return err
+	})
+	if reused {
+		log.Printf("Refresh token reuse detected, revoked its token family")
+		return nil, ErrInvalidRefreshToken
+	}
+	return pair, err
+}
+
+// Revoke 登出：撤銷目前的 access token 及其 refresh token
+func (s *tokenService) Revoke(claims *token.Claims, refreshToken string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if claims != nil && claims.ID != "" {
+			record := &entity.RevokedToken{
+				JTI:       claims.ID,
+				CreatedAt: time.Now(),
+				ExpiresAt: claims.ExpiresAt.Time,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error; err != nil {
+				return err
+			}
+		}
+		if refreshToken == "" {
+			return nil
+		}
+
+		query := tx.Model(&entity.RefreshToken{}).
+			Where("token_hash = ? AND revoked_at IS NULL", hashToken(refreshToken))
+		if claims != nil {
+			// 只能撤銷自己的 refresh token
+			query = query.Where("user_id = ?", claims.Subject)
+		}
+		return query.Update("revoked_at", time.Now()).Error
+	})
+}
+
+// RevokeUser 撤銷使用者所有的 refresh token，已發出的 access token 在短時間內自然過期
+func (s *tokenService) RevokeUser(userID int) error {
+	return s.db.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// ValidateAccessToken 驗證 access token 的簽章、有效期限及是否已被撤銷
+func (s *tokenService) ValidateAccessToken(tokenString string) (*token.Claims, error) {
+	return s.validate(tokenString, "")
+}
+
+// ValidateChallenge 驗證兩步驟驗證用的 token，scope 必須相符
+func (s *tokenService) ValidateChallenge(tokenString, scope string) (*token.Claims, error) {
+	return s.validate(tokenString, scope)
+}
+
+func (s *tokenService) validate(tokenString, scope string) (*token.Claims, error) {
+	claims := &token.Claims{}
+	if err := s.keys.Parse(tokenString, claims, jwt.WithExpirationRequired()); err != nil {
+		return nil, fmt.Errorf("%w: %v", token.ErrInvalidToken, err)
+	}
+	if _, err := claims.UserID(); err != nil || claims.OperatorID == 0 || claims.ID == "" || claims.Scope != scope {
+		return nil, token.ErrInvalidToken
+	}
+
+	var count int64
+	if err := s.db.Model(&entity.RevokedToken{}).Where("jti = ?", claims.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, token.ErrRevoked
+	}
+	return claims, nil
+}
+
+// issuePair 簽發 access token 並在指定的 family 中寫入新的 refresh token
+func (s *tokenService) issuePair(tx *gorm.DB, user *entity.User, familyID string) (*TokenPair, error) {
+	accessToken, accessExpiresAt, err := s.signToken(user, "", s.config.JWT.ExpiresIn)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := "rt_" + utils.NewID() + utils.NewID()
+	record := &entity.RefreshToken{
+		CreatedAt: time.Now(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.config.JWT.RefreshExpiresIn),
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// signToken 簽發 token，scope 為空時為 access token
+func (s *tokenService) signToken(user *entity.User, scope string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	permissions := []string{}
+	if scope == "" {
+		permissions = user.EffectivePermissions()
+	}
+	claims := token.Claims{
+		Name:        user.Name,
+		Role:        user.Role,
+		OperatorID:  user.OperatorID,
+		Permissions: permissions,
+		Scope:       scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.NewID(),
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := s.keys.Sign(claims)
+	return signed, expiresAt, err
+}
+
+// JWKS 返回驗證 access token 用的公鑰，供其他服務驗證
+func (s *tokenService) JWKS() token.JWKS {
+	return s.keys.JWKS()
+}
+
+func (s *tokenService) revokeFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// cleanupRevokedTokens 定期刪除已過期的撤銷紀錄
+func (s *tokenService) cleanupRevokedTokens() {
+	ticker := time.NewTicker(revokedTokenCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.db.Where("expires_at < ?", time.Now()).Delete(&entity.RevokedToken{}).Error; err != nil {
+			log.Printf("Failed to clean up revoked tokens: %v", err)
+		}
+	}
+}
+
+// hashToken 返回 refresh token 的 SHA-256 雜湊
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/totp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTwoFactorRequired    = errors.New("two-factor authentication must be enabled for this account")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrolment has not been started")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+const (
+	// recoveryCodeCount 每次產生的備用碼數量
+	recoveryCodeCount = 10
+	// recoveryCodeLength 備用碼長度，顯示時每 5 個字元以 - 分隔
+	recoveryCodeLength = 10
+	// totpSkew 容許前後幾個時間區段的時鐘誤差
+	totpSkew = 1
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorEnrollment 設定兩步驟驗證時返回的金鑰
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorService 管理 TOTP 兩步驟驗證及備用碼
+type TwoFactorService interface {
+	Enroll(operatorID, userID int) (*TwoFactorEnrollment, error)
+	Confirm(operatorID, userID int, code string) ([]string, error)
+	Verify(operatorID, userID int, code string) error
+	Disable(operatorID, userID int, code string) error
+}
+
+type twoFactorService struct {
+	db     *gorm.DB
+	config *config.Config
+	tokens TokenService
+}
+
+func NewTwoFactorService(db *gorm.DB, cfg *config.Config, tokens TokenService) TwoFactorService {
+	return &twoFactorService{
+		db:     db,
+		config: cfg,
+		tokens: tokens,
+	}
+}
+
+// Enroll 產生新的金鑰，以驗證碼確認後才會啟用；重新設定會取代尚未確認的金鑰
+func (s *twoFactorService) Enroll(operatorID, userID int) (*TwoFactorEnrollment, error) {
+	user, err := s.user(s.db, operatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.Model(user).Updates(map[string]interface{}{
+		"totp_secret": secret,
+		"updated_at":  time.Now(),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	account := user.Phone
+	if account == "" {
+		account = user.Name
+	}
+	return &TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.config.TwoFactor.Issuer, account, secret),
+	}, nil
+}
+
+// Confirm 以驗證碼確認金鑰並啟用兩步驟驗證，返回只顯示一次的備用碼
+// 啟用後撤銷使用者所有的 refresh token，其他裝置需重新登入
+func (s *twoFactorService) Confirm(operatorID, userID int, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.user(tx.Clauses(clause.Locking{Strength: "UPDATE"}), operatorID, userID)
+		if err != nil {
+			return err
+		}
+		if user.TwoFactorEnabled() {
+			return ErrTwoFactorEnabled
+		}
+		if user.TOTPSecret == nil {
+			return ErrTwoFactorNotEnrolled
+		}
+
+		step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		now := time.Now()
+		err = tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled_at": now,
+			"totp_last_step":  step,
+			"updated_at":      now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RevokeUser(userID); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify 檢查 TOTP 驗證碼或備用碼，驗證碼及備用碼都只能使用一次
+func (s *twoFactorService) Verify(operatorID, userID int, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.user(tx.Clauses(clause.Locking{Strength: "UPDATE"}), operatorID, userID)
+		if err != nil {
+			return err
+		}
+		if !user.TwoFactorEnabled() {
+			return ErrTwoFactorNotEnabled
+		}
+		return s.useCode(tx, user, code)
+	})
+}
+
+// Disable 停用兩步驟驗證，擁有管理權限的使用者不能停用
+func (s *twoFactorService) Disable(operatorID, userID int, code string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.user(tx.Clauses(clause.Locking{Strength: "UPDATE"}), operatorID, userID)
+		if err != nil {
+			return err
+		}
+		if !user.TwoFactorEnabled() {
+			return ErrTwoFactorNotEnabled
+		}
+		if user.RequiresTwoFactor() {
+			return ErrTwoFactorRequired
+		}
+		if err := s.useCode(tx, user, code); err != nil {
+			return err
+		}
+
+		err = tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":     nil,
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+			"updated_at":      time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&entity.RecoveryCode{}).Error
+	})
+}
+
+// useCode 使用 6 位數的 TOTP 驗證碼或備用碼
+func (s *twoFactorService) useCode(tx *gorm.DB, user *entity.User, code string) error {
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(*user.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok || step <= user.TOTPLastStep {
+			return ErrInvalidTwoFactorCode
+		}
+		return tx.Model(user).Update("totp_last_step", step).Error
+	}
+
+	result := tx.Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes 刪除舊的備用碼並產生新的一組
+func (s *twoFactorService) replaceRecoveryCodes(tx *gorm.DB, userID int) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]entity.RecoveryCode, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, entity.RecoveryCode{
+			CreatedAt: now,
+			UserID:    userID,
+			CodeHash:  hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) user(db *gorm.DB, operatorID, userID int) (*entity.User, error) {
+	var user entity.User
+	err := db.Where("operator_id = ?", operatorID).Take(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// randomRecoveryCode 產生形如 abcde-fghij 的備用碼
+func randomRecoveryCode() (string, error) {
+	data := make([]byte, recoveryCodeLength*5/8)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(data))
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode 忽略大小寫及分隔符號
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/internal/pkg/totp"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"abcde-fghij", "abcdefghij"},
+		{"ABCDE-FGHIJ", "abcdefghij"},
+		{"abcdefghij", "abcdefghij"},
+		{"  abcde-fghij \n", "abcdefghij"},
+		{"ab-cde-fg-hij", "abcdefghij"},
+	}
+	for _, tt := range tests {
+		if got := normalizeRecoveryCode(tt.code); got != tt.want {
+			t.Errorf("normalizeRecoveryCode(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRandomRecoveryCode(t *testing.T) {
+	code, err := randomRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != recoveryCodeLength+1 || code[5] != '-' {
+		t.Errorf("randomRecoveryCode = %q, want the form abcde-fghij", code)
+	}
+	if normalized := normalizeRecoveryCode(code); len(normalized) != recoveryCodeLength {
+		t.Errorf("normalizeRecoveryCode(%q) = %q, want %d characters", code, normalized, recoveryCodeLength)
+	}
+}
+
+// TestUseCodeRejectsReusedStep 已使用過的時間區段在寫入資料庫之前就被拒絕
+func TestUseCodeRejectsReusedStep(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := totp.Step(time.Now())
+	code, err := totp.Code(secret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &twoFactorService{}
+	for _, lastStep := range []int64{current, current + 1} {
+		user := &entity.User{ID: 1, TOTPSecret: &secret, TOTPLastStep: lastStep}
+		if err := s.useCode(nil, user, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Errorf("useCode with last step %d = %v, want %v", lastStep, err, ErrInvalidTwoFactorCode)
+		}
+	}
+}
@@ -1,54 +1,131 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"passontw-slot-game/internal/config"
 	"passontw-slot-game/internal/domain/entity"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserBlocked     = errors.New("user is blocked")
+	ErrInvalidRole     = errors.New("invalid role or permission")
+	ErrPhoneRegistered = errors.New("phone is already registered")
+	ErrInvalidPassword = errors.New("invalid password")
+	ErrSamePassword    = errors.New("new password must differ from the current password")
+	ErrUserNotDeleted  = errors.New("user is not deleted")
+)
+
+// dummyPasswordHash 電話不存在時用於比對的密碼雜湊
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// UserService 管理使用者，所有查詢都限定在單一營運商之內
 type UserService interface {
-	CreateUser(name, phone, password string) (*entity.User, error)
-	GetUsers(page, pageSize int) ([]entity.User, int64, error)
-	Login(phone, password string) (*entity.User, string, error)
+	CreateUser(operatorID int, name, phone, password string) (*entity.User, error)
+	GetUsers(operatorID, page, pageSize int) ([]entity.User, int64, error)
+	Login(operatorID int, phone, password string) (*entity.User, error)
+	SearchUsers(operatorID int, query string, page, pageSize int) ([]entity.User, int64, error)
+	GetUser(operatorID, userID int) (*entity.User, error)
+	Block(operatorID, userID int, reason string) (*entity.User, error)
+	Unblock(operatorID, userID int) (*entity.User, error)
+	SetRole(operatorID, userID int, role string, permissions []string) (*entity.User, error)
+	ChangePassword(operatorID, userID int, currentPassword, newPassword string) error
+	ResetPassword(operatorID int, phone, newPassword string) (*entity.User, error)
+	UpdateUser(operatorID, userID int, update UserUpdate) (*entity.User, error)
+	DeleteUser(operatorID, userID int) error
+	RestoreUser(operatorID, userID int) (*entity.User, error)
+}
+
+// UserUpdate 要更新的使用者資料，nil 的欄位不變更
+type UserUpdate struct {
+	Name  *string
+	Phone *string
 }
 
 type userService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	tokens TokenService
+}
+
+func NewUserService(db *gorm.DB, cfg *config.Config, tokens TokenService, operators OperatorService) (UserService, error) {
+	s := &userService{
+		db:     db,
+		tokens: tokens,
+	}
+	if err := s.seedAdmin(operators.PlatformOperatorID(), cfg.Admin); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
-func NewUserService(db *gorm.DB) UserService {
-	return &userService{
-		db: db,
+// seedAdmin 將 ADMIN_PHONE 對應的平台使用者設為管理者，電話尚未註冊時以 ADMIN_PASSWORD 建立
+// 每次啟動都會執行，不會覆寫既有使用者的密碼，之後的管理者由此管理者以管理介面指派
+func (s *userService) seedAdmin(operatorID int, admin config.AdminConfig) error {
+	if admin.Phone == "" {
+		return nil
 	}
+
+	var user entity.User
+	err := s.db.Where("operator_id = ? AND phone = ?", operatorID, admin.Phone).Take(&user).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if len(admin.Password) < 6 {
+			return errors.New("ADMIN_PASSWORD of at least 6 characters is required to create the admin user")
+		}
+		created, err := s.CreateUser(operatorID, "admin", admin.Phone, admin.Password)
+		if err != nil {
+			return err
+		}
+		user = *created
+	case err != nil:
+		return err
+	case user.Role == entity.RoleAdmin:
+		return nil
+	}
+
+	user.UpdatedAt = time.Now()
+	user.Role = entity.RoleAdmin
+	return s.db.Model(&user).Select("role", "updated_at").Updates(&user).Error
 }
 
-func (s *userService) CreateUser(name, phone, password string) (*entity.User, error) {
+func (s *userService) CreateUser(operatorID int, name, phone, password string) (*entity.User, error) {
 	hashPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	user := &entity.User{
-		Name:     name,
-		Phone:    phone,
-		Password: string(hashPassword),
+		OperatorID: operatorID,
+		Name:       name,
+		Phone:      phone,
+		Password:   string(hashPassword),
+		Role:       entity.RolePlayer,
+		Status:     entity.UserStatusActive,
 	}
 
+	// 同一營運商中未刪除的使用者電話不可重複，由唯一索引保證
 	if err := s.db.Create(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrPhoneRegistered
+		}
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *userService) GetUsers(page, pageSize int) ([]entity.User, int64, error) {
+func (s *userService) GetUsers(operatorID, page, pageSize int) ([]entity.User, int64, error) {
 	var users []entity.User
 	var total int64
 
+	query := s.db.Model(&entity.User{}).Where("operator_id = ?", operatorID)
+
 	// 獲取總數
-	if err := s.db.Model(&entity.User{}).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -56,45 +133,286 @@ func (s *userService) GetUsers(page, pageSize int) ([]entity.User, int64, error)
 	offset := (page - 1) * pageSize
 
 	// 查詢用戶列表
-	if err := s.db.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+	if err := query.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return users, total, nil
 }
 
-func (s *userService) Login(phone, password string) (*entity.User, string, error) {
+// Login 以電話及密碼登入，電話不存在時仍比對一次密碼，回應時間不會透露電話是否已註冊
+func (s *userService) Login(operatorID int, phone, password string) (*entity.User, error) {
 	var user entity.User
 
-	fmt.Println("phone: " + phone)
 	// 查找用戶
-	if err := s.db.Where("phone = ?", phone).First(&user).Error; err != nil {
+	if err := s.db.Where("operator_id = ? AND phone = ?", operatorID, phone).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", errors.New("user not found")
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+			return nil, ErrUserNotFound
 		}
-		return nil, "", err
+		return nil, err
 	}
 
 	// 驗證密碼
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, "", errors.New("invalid password")
+		return nil, ErrInvalidPassword
 	}
 
-	// 生成 JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  user.ID,
-		"name": user.Name,
-		"exp":  time.Now().Add(time.Hour * 24).Unix(), // 24小時過期
-	})
-
-	// 這裡使用一個簡單的密鑰，實際應用中應該使用環境變量或配置文件
-	tokenString, err := token.SignedString([]byte(config.GetEnv("JWT_SECRET", "your-secret-key")))
-	if err != nil {
-		return nil, "", err
+	if user.Status == entity.UserStatusBlocked {
+		return nil, ErrUserBlocked
 	}
 
 	// 清理敏感資訊
 	user.Password = ""
 
-	return &user, tokenString, nil
+	return &user, nil
+}
+
+// SearchUsers 以名稱、電話或 ID 搜尋營運商旗下的使用者，query 為空時返回全部
+func (s *userService) SearchUsers(operatorID int, query string, page, pageSize int) ([]entity.User, int64, error) {
+	var users []entity.User
+	var total int64
+
+	db := s.db.Model(&entity.User{}).Where("operator_id = ?", operatorID)
+	if query = strings.TrimSpace(query); query != "" {
+		pattern := "%" + escapeLike(query) + "%"
+		if id, err := strconv.Atoi(query); err == nil {
+			db = db.Where("id = ? OR name ILIKE ? OR phone ILIKE ?", id, pattern, pattern)
+		} else {
+			db = db.Where("name ILIKE ? OR phone ILIKE ?", pattern, pattern)
+		}
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetUser 查詢營運商旗下的使用者
+func (s *userService) GetUser(operatorID, userID int) (*entity.User, error) {
+	var user entity.User
+	err := s.db.Where("operator_id = ?", operatorID).Take(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Block 停用使用者，停用期間無法登入及開始新的遊戲局
+func (s *userService) Block(operatorID, userID int, reason string) (*entity.User, error) {
+	user, err := s.GetUser(operatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.UpdatedAt = now
+	user.Status = entity.UserStatusBlocked
+	user.BlockedAt = &now
+	user.BlockedReason = &reason
+	if err := s.db.Model(user).Select("status", "blocked_at", "blocked_reason", "updated_at").Updates(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Unblock 恢復被停用的使用者
+func (s *userService) Unblock(operatorID, userID int) (*entity.User, error) {
+	user, err := s.GetUser(operatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.UpdatedAt = time.Now()
+	user.Status = entity.UserStatusActive
+	user.BlockedAt = nil
+	user.BlockedReason = nil
+	if err := s.db.Model(user).Select("status", "blocked_at", "blocked_reason", "updated_at").Updates(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetRole 設定使用者的角色及角色以外額外授予的權限，下次登入後生效
+func (s *userService) SetRole(operatorID, userID int, role string, permissions []string) (*entity.User, error) {
+	if !entity.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	for _, permission := range permissions {
+		if !entity.ValidPermission(permission) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRole, permission)
+		}
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUser(operatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.UpdatedAt = time.Now()
+	user.Role = role
+	user.Permissions = string(data)
+	if err := s.db.Model(user).Select("role", "permissions", "updated_at").Updates(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser 更新使用者的名稱或電話
+// 電話是登入及重設密碼使用的帳號，電話變更後撤銷使用者所有的 refresh token，須以新電話重新登入
+func (s *userService) UpdateUser(operatorID, userID int, update UserUpdate) (*entity.User, error) {
+	user, err := s.GetUser(operatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []interface{}{}
+	if update.Name != nil {
+		user.Name = *update.Name
+		columns = append(columns, "name")
+	}
+	phoneChanged := false
+	if update.Phone != nil && *update.Phone != user.Phone {
+		user.Phone = *update.Phone
+		columns = append(columns, "phone")
+		phoneChanged = true
+	}
+	if len(columns) == 0 {
+		return user, nil
+	}
+
+	user.UpdatedAt = time.Now()
+	err = s.db.Model(user).Select("updated_at", columns...).Updates(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrPhoneRegistered
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if phoneChanged {
+		if err := s.tokens.RevokeUser(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// DeleteUser 軟刪除使用者並撤銷其所有的 refresh token，刪除後無法登入，可由管理者恢復
+func (s *userService) DeleteUser(operatorID, userID int) error {
+	result := s.db.Where("operator_id = ?", operatorID).Delete(&entity.User{}, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return s.tokens.RevokeUser(userID)
+}
+
+// RestoreUser 恢復已刪除的使用者，電話已被其他使用者註冊時無法恢復
+func (s *userService) RestoreUser(operatorID, userID int) (*entity.User, error) {
+	var user entity.User
+	err := s.db.Unscoped().Where("operator_id = ?", operatorID).Take(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.DeletedAt.Valid {
+		return nil, ErrUserNotDeleted
+	}
+
+	user.UpdatedAt = time.Now()
+	user.DeletedAt = gorm.DeletedAt{}
+	err = s.db.Unscoped().Model(&user).Select("deleted_at", "updated_at").Updates(&user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrPhoneRegistered
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword 驗證目前的密碼後設定新密碼
+func (s *userService) ChangePassword(operatorID, userID int, currentPassword, newPassword string) error {
+	user, err := s.GetUser(operatorID, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return ErrInvalidPassword
+	}
+	if currentPassword == newPassword {
+		return ErrSamePassword
+	}
+	return s.setPassword(user, newPassword)
+}
+
+// ResetPassword 為已驗證電話的使用者重設密碼
+func (s *userService) ResetPassword(operatorID int, phone, newPassword string) (*entity.User, error) {
+	var user entity.User
+	err := s.db.Where("operator_id = ? AND phone = ?", operatorID, phone).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setPassword(&user, newPassword); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// setPassword 寫入新密碼並撤銷使用者所有的 refresh token，其他裝置需重新登入
+func (s *userService) setPassword(user *entity.User, password string) error {
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user.UpdatedAt = time.Now()
+	user.Password = string(hashPassword)
+	if err := s.db.Model(user).Select("password", "updated_at").Updates(user).Error; err != nil {
+		return err
+	}
+	return s.tokens.RevokeUser(user.ID)
+}
+
+// checkActiveUser 確認使用者屬於該營運商且未被停用
+func checkActiveUser(db *gorm.DB, operatorID, userID int) error {
+	var user entity.User
+	err := db.Select("id", "status").Where("operator_id = ?", operatorID).Take(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if user.Status == entity.UserStatusBlocked {
+		return ErrUserBlocked
+	}
+	return nil
+}
+
+// escapeLike 跳脫 LIKE 樣式中的萬用字元
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
 }
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"errors"
+	"passontw-slot-game/internal/config"
+	"passontw-slot-game/internal/domain/entity"
+	"passontw-slot-game/pkg/money"
+	"passontw-slot-game/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrWalletUnavailable = errors.New("wallet is unavailable")
+	ErrOperatorManaged   = errors.New("balance is managed by the operator")
+	ErrReasonRequired    = errors.New("reason is required")
+)
+
+// Transfer 一筆錢包交易，TransactionID 為冪等鍵，相同 ID 的重複請求只處理一次
+type Transfer struct {
+	TransactionID string
+	OperatorID    int
+	UserID        int
+	RoundID       string
+	Amount        money.Money
+}
+
+// WalletService 管理使用者各貨幣的餘額
+// Debit 及 Credit 的 tx 參數讓內部帳本與遊戲局一併提交或回滾；
+// 外部錢包無法參與資料庫交易，交易失敗時呼叫端需以 Rollback 撤銷扣款（扣款請求失敗時營運商也可能已經扣款），
+// 派彩則在遊戲局提交後才進行
+type WalletService interface {
+	Balances(userID int) ([]money.Money, error)
+	Balance(userID int, currency money.Currency) (money.Money, error)
+	Debit(tx *gorm.DB, transfer Transfer) (money.Money, error)
+	Credit(tx *gorm.DB, transfer Transfer) (money.Money, error)
+	Rollback(transfer Transfer) error
+	Deposit(operatorID, userID, actorID int, amount money.Money, reason string) (money.Money, error)
+	Adjust(operatorID, userID, actorID int, amount money.Money, reason string) (money.Money, error)
+}
+
+// NewWalletService 建立依營運商選擇錢包的錢包服務
+// 設定錢包網址的營運商使用外部錢包，其他營運商（例如平台本身的使用者）使用內部帳本
+func NewWalletService(db *gorm.DB, cfg *config.Config) WalletService {
+	return &operatorWallet{
+		db:       db,
+		ledger:   NewLedgerWallet(db),
+		seamless: NewSeamlessWallet(db, cfg),
+	}
+}
+
+// operatorWallet 根據使用者所屬營運商的錢包設定，將請求轉給內部帳本或外部錢包
+type operatorWallet struct {
+	db       *gorm.DB
+	ledger   WalletService
+	seamless WalletService
+}
+
+// Balances 返回使用者所有貨幣的餘額
+func (s *operatorWallet) Balances(userID int) ([]money.Money, error) {
+	wallet, err := s.userWallet(userID)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.Balances(userID)
+}
+
+// Balance 返回使用者指定貨幣的餘額
+func (s *operatorWallet) Balance(userID int, currency money.Currency) (money.Money, error) {
+	wallet, err := s.userWallet(userID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return wallet.Balance(userID, currency)
+}
+
+// Debit 從使用者所屬營運商的錢包扣款
+func (s *operatorWallet) Debit(tx *gorm.DB, transfer Transfer) (money.Money, error) {
+	wallet, err := s.operatorWallet(transfer.OperatorID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return wallet.Debit(tx, transfer)
+}
+
+// Credit 派彩至使用者所屬營運商的錢包
+func (s *operatorWallet) Credit(tx *gorm.DB, transfer Transfer) (money.Money, error) {
+	wallet, err := s.operatorWallet(transfer.OperatorID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return wallet.Credit(tx, transfer)
+}
+
+// Rollback 撤銷使用者所屬營運商錢包的扣款
+func (s *operatorWallet) Rollback(transfer Transfer) error {
+	wallet, err := s.operatorWallet(transfer.OperatorID)
+	if err != nil {
+		return err
+	}
+	return wallet.Rollback(transfer)
+}
+
+// Deposit 入帳至營運商的內部帳本，使用外部錢包的營運商返回 ErrOperatorManaged
+func (s *operatorWallet) Deposit(operatorID, userID, actorID int, amount money.Money, reason string) (money.Money, error) {
+	wallet, err := s.operatorWallet(operatorID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return wallet.Deposit(operatorID, userID, actorID, amount, reason)
+}
+
+// Adjust 調整營運商內部帳本的餘額，使用外部錢包的營運商返回 ErrOperatorManaged
+func (s *operatorWallet) Adjust(operatorID, userID, actorID int, amount money.Money, reason string) (money.Money, error) {
+	wallet, err := s.operatorWallet(operatorID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return wallet.Adjust(operatorID, userID, actorID, amount, reason)
+}
+
+// userWallet 返回使用者所屬營運商使用的錢包
+func (s *operatorWallet) userWallet(userID int) (WalletService, error) {
+	var user entity.User
+	if err := s.db.Unscoped().Select("id", "operator_id").Take(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return s.operatorWallet(user.OperatorID)
+}
+
+// operatorWallet 返回營運商使用的錢包，設定錢包網址時為外部錢包，否則為內部帳本
+func (s *operatorWallet) operatorWallet(operatorID int) (WalletService, error) {
+	var operator entity.Operator
+	if err := s.db.Select("id", "wallet_url").Take(&operator, operatorID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOperatorNotFound
+		}
+		return nil, err
+	}
+	if operator.WalletURL != "" {
+		return s.seamless, nil
+	}
+	return s.ledger, nil
+}
+
+// ledgerWallet 以 Postgres 保存餘額及交易明細的內部帳本
+type ledgerWallet struct {
+	db *gorm.DB
+}
+
+func NewLedgerWallet(db *gorm.DB) WalletService {
+	return &ledgerWallet{db: db}
+}
+
+// Balances 返回使用者所有貨幣的餘額
+func (s *ledgerWallet) Balances(userID int) ([]money.Money, error) {
+	var wallets []entity.Wallet
+	if err := s.db.Where("user_id = ?", userID).Order("currency").Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+
+	balances := make([]money.Money, 0, len(wallets))
+	for _, wallet := range wallets {
+		balances = append(balances, money.New(wallet.Balance, money.Currency(wallet.Currency)))
+	}
+	return balances, nil
+}
+
+// Balance 返回使用者指定貨幣的餘額，尚未開立錢包時為 0
+func (s *ledgerWallet) Balance(userID int, currency money.Currency) (money.Money, error) {
+	var wallet entity.Wallet
+	err := s.db.Where("user_id = ? AND currency = ?", userID, string(currency)).First(&wallet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return money.Zero(currency), nil
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+	return money.New(wallet.Balance, currency), nil
+}
+
+// Debit 扣除下注金額，餘額不足時返回 ErrInsufficientFunds
+func (s *ledgerWallet) Debit(tx *gorm.DB, transfer Transfer) (money.Money, error) {
+	if !transfer.Amount.IsPositive() {
+		return money.Money{}, ErrInvalidAmount
+	}
+	delta := money.Zero(transfer.Amount.Currency()).Sub(transfer.Amount)
+	return s.apply(tx, transfer.OperatorID, transfer.UserID, entity.WalletTxBet, transfer.TransactionID, delta, &transfer.RoundID, nil, nil)
+}
+
+// Credit 派發獎金，金額為 0 時不寫入明細而只返回目前餘額
+func (s *ledgerWallet) Credit(tx *gorm.DB, transfer Transfer) (money.Money, error) {
+	if transfer.Amount.IsNegative() {
+		return money.Money{}, ErrInvalidAmount
+	}
+	if transfer.Amount.IsZero() {
+		return s.apply(tx, transfer.OperatorID, transfer.UserID, "", "", transfer.Amount, nil, nil, nil)
+	}
+	return s.apply(tx, transfer.OperatorID, transfer.UserID, entity.WalletTxWin, transfer.TransactionID, transfer.Amount, &transfer.RoundID, nil, nil)
+}
+
+// Rollback 退回已入帳的扣款，扣款不存在（例如已隨資料庫交易回滾）時不做任何事
+func (s *ledgerWallet) Rollback(transfer Transfer) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var debit entity.WalletTransaction
+		err := tx.Where("transaction_id = ? AND type = ?", transfer.TransactionID, entity.WalletTxBet).First(&debit).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		refund := money.New(-debit.Amount, money.Currency(debit.Currency))
+		_, err = s.apply(tx, debit.OperatorID, debit.UserID, entity.WalletTxRollback, transfer.TransactionID+"-rollback", refund, debit.RoundID, nil, nil)
+		return err
+	})
+}
+
+// Deposit 為營運商旗下使用者的錢包入帳，reason 記錄入帳原因，actorID 為執行入帳的管理者
+func (s *ledgerWallet) Deposit(operatorID, userID, actorID int, amount money.Money, reason string) (money.Money, error) {
+	if !amount.IsPositive() {
+		return money.Money{}, ErrInvalidAmount
+	}
+	return s.manual(operatorID, userID, actorID, entity.WalletTxDeposit, amount, reason)
+}
+
+// Adjust 由管理者調整使用者的餘額，amount 為負數時扣除，調整後餘額不得為負數
+func (s *ledgerWallet) Adjust(operatorID, userID, actorID int, amount money.Money, reason string) (money.Money, error) {
+	if amount.IsZero() {
+		return money.Money{}, ErrInvalidAmount
+	}
+	return s.manual(operatorID, userID, actorID, entity.WalletTxAdjust, amount, reason)
+}
+
+// manual 寫入人工入帳或調整，reason 及執行的管理者記錄於交易明細
+func (s *ledgerWallet) manual(operatorID, userID, actorID int, txType string, amount money.Money, reason string) (money.Money, error) {
+	if strings.TrimSpace(reason) == "" {
+		return money.Money{}, ErrReasonRequired
+	}
+
+	var balance money.Money
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&entity.User{}).Where("id = ? AND operator_id = ?", userID, operatorID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrUserNotFound
+		}
+
+		var err error
+		balance, err = s.apply(tx, operatorID, userID, txType, utils.NewID(), amount, nil, &reason, &actorID)
+		return err
+	})
+	return balance, err
+}
+
+// apply 鎖定錢包並異動餘額，txType 為空時只讀取餘額；相同 transactionID 已處理過時直接返回當時的餘額
+func (s *ledgerWallet) apply(tx *gorm.DB, operatorID, userID int, txType, transactionID string, delta money.Money, roundID, reason *string, actorID *int) (money.Money, error) {
+	currency := delta.Currency()
+
+	if txType != "" {
+		var existing entity.WalletTransaction
+		err := tx.Where("transaction_id = ?", transactionID).First(&existing).Error
+		if err == nil {
+			return money.New(existing.BalanceAfter, money.Currency(existing.Currency)), nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return money.Money{}, err
+		}
+	}
+
+	// 第一次使用該貨幣時開立錢包
+	wallet := entity.Wallet{OperatorID: operatorID, UserID: userID, Currency: string(currency)}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&wallet).Error; err != nil {
+		return money.Money{}, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("operator_id = ? AND user_id = ? AND currency = ?", operatorID, userID, string(currency)).
+		First(&wallet).Error; err != nil {
+		return money.Money{}, err
+	}
+
+	balance := money.New(wallet.Balance, currency).Add(delta)
+	if txType == "" {
+		return balance, nil
+	}
+	if balance.IsNegative() {
+		return money.Money{}, ErrInsufficientFunds
+	}
+
+	err := tx.Model(&wallet).Updates(map[string]interface{}{
+		"balance":    balance.Minor(),
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	record := &entity.WalletTransaction{
+		TransactionID: transactionID,
+		WalletID:      wallet.ID,
+		OperatorID:    operatorID,
+		UserID:        userID,
+		Currency:      string(currency),
+		Type:          txType,
+		Amount:        delta.Minor(),
+		BalanceAfter:  balance.Minor(),
+		RoundID:       roundID,
+		Reason:        reason,
+		ActorID:       actorID,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return money.Money{}, err
+	}
+	return balance, nil
+}
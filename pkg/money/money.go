@@ -0,0 +1,277 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid money amount")
+	ErrTooManyDecimals  = errors.New("amount has more decimal places than the currency allows")
+	ErrAmountOverflow   = errors.New("money amount overflows")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Currency 貨幣代碼，例如 TWD、USD
+type Currency string
+
+// exponents 各貨幣最小單位的小數位數，未列出的貨幣預設為 2 位
+var exponents = map[Currency]int{
+	"JPY":  0,
+	"KRW":  0,
+	"BTC":  8,
+	"ETH":  8,
+	"USDT": 6,
+}
+
+// Exponent 返回貨幣最小單位的小數位數
+func (c Currency) Exponent() int {
+	if exp, ok := exponents[c]; ok {
+		return exp
+	}
+	return 2
+}
+
+// RoundingMode 金額乘以倍數後無法以最小單位表示時的捨入方式
+type RoundingMode int
+
+const (
+	// RoundDown 向零捨去，用於派彩以避免多付
+	RoundDown RoundingMode = iota
+	// RoundHalfUp 四捨五入，用於換算及報表
+	RoundHalfUp
+)
+
+// Money 以最小單位整數儲存的定點金額，避免浮點數的累積誤差
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New 以最小單位建立金額，例如 New(150, "TWD") 代表 1.50 TWD
+func New(minor int64, currency Currency) Money {
+	return Money{amount: minor, currency: currency}
+}
+
+// Zero 返回指定貨幣的零金額
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// Parse 解析十進位字串，小數位數超過貨幣最小單位時返回錯誤而不捨入
+// 最多只能有一個正負號，超出 int64 範圍時返回 ErrAmountOverflow
+func Parse(value string, currency Currency) (Money, error) {
+	value = strings.TrimSpace(value)
+	unsigned := value
+	negative := false
+	switch {
+	case strings.HasPrefix(unsigned, "-"):
+		negative = true
+		unsigned = unsigned[1:]
+	case strings.HasPrefix(unsigned, "+"):
+		unsigned = unsigned[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(unsigned, ".")
+	if whole == "" && fraction == "" || !digitsOnly(whole) || !digitsOnly(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	exp := currency.Exponent()
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exp {
+		return Money{}, fmt.Errorf("%w: %s allows %d", ErrTooManyDecimals, currency, exp)
+	}
+
+	var amount int64
+	if digits := strings.TrimLeft(whole+fraction+strings.Repeat("0", exp-len(fraction)), "0"); digits != "" {
+		var err error
+		if amount, err = strconv.ParseInt(digits, 10, 64); err != nil {
+			return Money{}, ErrAmountOverflow
+		}
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{amount: amount, currency: currency}, nil
+}
+
+// MustParse 與 Parse 相同，但解析失敗時 panic，只用於常數
+func MustParse(value string, currency Currency) Money {
+	m, err := Parse(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func digitsOnly(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor 返回以最小單位表示的金額
+func (m Money) Minor() int64 {
+	return m.amount
+}
+
+// Currency 返回貨幣代碼
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add 相加兩個相同貨幣的金額，貨幣不同或結果超出 int64 範圍時 panic
+func (m Money) Add(other Money) Money {
+	m.mustMatch(other)
+	sum := m.amount + other.amount
+	if (other.amount > 0 && sum < m.amount) || (other.amount < 0 && sum > m.amount) {
+		panic(fmt.Errorf("%w: %s + %s", ErrAmountOverflow, m, other))
+	}
+	return Money{amount: sum, currency: m.currency}
+}
+
+// Sub 相減兩個相同貨幣的金額，貨幣不同或結果超出 int64 範圍時 panic
+func (m Money) Sub(other Money) Money {
+	m.mustMatch(other)
+	diff := m.amount - other.amount
+	if (other.amount > 0 && diff > m.amount) || (other.amount < 0 && diff < m.amount) {
+		panic(fmt.Errorf("%w: %s - %s", ErrAmountOverflow, m, other))
+	}
+	return Money{amount: diff, currency: m.currency}
+}
+
+// Cmp 比較兩個相同貨幣的金額，小於、等於、大於分別返回 -1、0、1
+func (m Money) Cmp(other Money) int {
+	m.mustMatch(other)
+	switch {
+	case m.amount < other.amount:
+		return -1
+	case m.amount > other.amount:
+		return 1
+	}
+	return 0
+}
+
+// Mul 乘以倍數並依指定方式捨入至最小單位，結果超出 int64 範圍時 panic
+// 倍數以其最短十進位表示計算，例如 0.1 視為精確的 1/10
+func (m Money) Mul(multiplier float64, mode RoundingMode) Money {
+	factor, ok := new(big.Rat).SetString(strconv.FormatFloat(multiplier, 'f', -1, 64))
+	if !ok {
+		panic(fmt.Sprintf("money: invalid multiplier %v", multiplier))
+	}
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.amount), factor)
+	amount, ok := round(product, mode)
+	if !ok {
+		panic(fmt.Errorf("%w: %s * %v", ErrAmountOverflow, m, multiplier))
+	}
+	return Money{amount: amount, currency: m.currency}
+}
+
+// round 將有理數捨入為整數，結果超出 int64 範圍時返回 false
+func round(r *big.Rat, mode RoundingMode) (int64, bool) {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if mode == RoundHalfUp && rem.Sign() != 0 {
+		// |rem| * 2 >= denom 時遠離零進位
+		twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+		if twice.Cmp(r.Denom()) >= 0 {
+			quo.Add(quo, big.NewInt(int64(r.Num().Sign())))
+		}
+	}
+	if !quo.IsInt64() {
+		return 0, false
+	}
+	return quo.Int64(), true
+}
+
+func (m Money) mustMatch(other Money) {
+	if m.currency != other.currency {
+		panic(fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency))
+	}
+}
+
+// String 以貨幣的小數位數格式化金額，例如 "1.50"
+func (m Money) String() string {
+	exp := m.currency.Exponent()
+	// 以 uint64 取絕對值，最小的 int64 取負數時不會溢位
+	amount := uint64(m.amount)
+	sign := ""
+	if m.amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatUint(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+type moneyJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON 以字串輸出金額，避免 JSON 數字的精度問題
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := Parse(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Convert 以匯率換算為另一種貨幣，rate 為一單位原貨幣可換得的目標貨幣數量（十進位字串）
+// 換算結果超出 int64 範圍時返回 ErrAmountOverflow
+func (m Money) Convert(to Currency, rate string, mode RoundingMode) (Money, error) {
+	factor, ok := new(big.Rat).SetString(rate)
+	if !ok || factor.Sign() <= 0 {
+		return Money{}, fmt.Errorf("money: invalid exchange rate %q", rate)
+	}
+
+	// 先換算為原貨幣的主單位，乘以匯率後再換算為目標貨幣的最小單位
+	amount := new(big.Rat).SetFrac(big.NewInt(m.amount), pow10(m.currency.Exponent()))
+	amount.Mul(amount, factor)
+	amount.Mul(amount, new(big.Rat).SetInt(pow10(to.Exponent())))
+	minor, ok := round(amount, mode)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s %s to %s", ErrAmountOverflow, m, m.currency, to)
+	}
+	return Money{amount: minor, currency: to}, nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
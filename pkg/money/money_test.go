@@ -0,0 +1,199 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency Currency
+		want     int64
+		err      error
+	}{
+		{"1.50", "TWD", 150, nil},
+		{"1.5", "TWD", 150, nil},
+		{" 10 ", "TWD", 1000, nil},
+		{"+5", "TWD", 500, nil},
+		{"-5", "TWD", -500, nil},
+		{".25", "USD", 25, nil},
+		{"3.", "USD", 300, nil},
+		{"1.2300", "USD", 123, nil},
+		{"0", "TWD", 0, nil},
+		{"1000", "JPY", 1000, nil},
+		{"0.00000001", "BTC", 1, nil},
+		{"1.123456", "USDT", 1123456, nil},
+		{"92233720368547758.07", "TWD", math.MaxInt64, nil},
+		{"1.234", "TWD", 0, ErrTooManyDecimals},
+		{"1.5", "JPY", 0, ErrTooManyDecimals},
+		{"", "TWD", 0, ErrInvalidAmount},
+		{".", "TWD", 0, ErrInvalidAmount},
+		{"abc", "TWD", 0, ErrInvalidAmount},
+		{"1,000", "TWD", 0, ErrInvalidAmount},
+		{"1e3", "TWD", 0, ErrInvalidAmount},
+		{"-+5", "TWD", 0, ErrInvalidAmount},
+		{"+-5", "TWD", 0, ErrInvalidAmount},
+		{"--5", "TWD", 0, ErrInvalidAmount},
+		{"- 5", "TWD", 0, ErrInvalidAmount},
+		{"92233720368547758.08", "TWD", 0, ErrAmountOverflow},
+		{"-92233720368547758.09", "TWD", 0, ErrAmountOverflow},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.value, tt.currency)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q, %s) error = %v, want %v", tt.value, tt.currency, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q, %s) unexpected error: %v", tt.value, tt.currency, err)
+			continue
+		}
+		if got.Minor() != tt.want || got.Currency() != tt.currency {
+			t.Errorf("Parse(%q, %s) = %d %s, want %d %s", tt.value, tt.currency, got.Minor(), got.Currency(), tt.want, tt.currency)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency Currency
+		want     string
+	}{
+		{150, "TWD", "1.50"},
+		{5, "TWD", "0.05"},
+		{0, "TWD", "0.00"},
+		{-150, "TWD", "-1.50"},
+		{-5, "USD", "-0.05"},
+		{1000, "JPY", "1000"},
+		{-1000, "JPY", "-1000"},
+		{1, "BTC", "0.00000001"},
+		{1123456, "USDT", "1.123456"},
+		{math.MaxInt64, "TWD", "92233720368547758.07"},
+		{math.MinInt64, "TWD", "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		if got := New(tt.amount, tt.currency).String(); got != tt.want {
+			t.Errorf("New(%d, %s).String() = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		amount     int64
+		multiplier float64
+		mode       RoundingMode
+		want       int64
+	}{
+		{100, 2.5, RoundDown, 250},
+		{100, 0.1, RoundDown, 10},
+		{333, 0.5, RoundDown, 166},
+		{333, 0.5, RoundHalfUp, 167},
+		{334, 0.5, RoundHalfUp, 167},
+		{101, 0.333, RoundDown, 33},
+		{101, 0.333, RoundHalfUp, 34},
+		{-333, 0.5, RoundDown, -166},
+		{-333, 0.5, RoundHalfUp, -167},
+		{150, 0, RoundDown, 0},
+	}
+
+	for _, tt := range tests {
+		got := New(tt.amount, "TWD").Mul(tt.multiplier, tt.mode)
+		if got.Minor() != tt.want {
+			t.Errorf("New(%d).Mul(%v, %d) = %d, want %d", tt.amount, tt.multiplier, tt.mode, got.Minor(), tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount string
+		from   Currency
+		to     Currency
+		rate   string
+		mode   RoundingMode
+		want   string
+		err    bool
+	}{
+		{"10.00", "USD", "TWD", "32.5", RoundHalfUp, "325.00", false},
+		{"100.00", "TWD", "USD", "0.030769230769", RoundHalfUp, "3.08", false},
+		{"100.00", "TWD", "USD", "0.030769230769", RoundDown, "3.07", false},
+		{"65.00", "TWD", "USD", "2/65", RoundDown, "2.00", false},
+		{"1000", "JPY", "TWD", "0.2143", RoundHalfUp, "214.30", false},
+		{"1.00", "USD", "JPY", "149.555", RoundHalfUp, "150", false},
+		{"1.00", "USD", "JPY", "149.555", RoundDown, "149", false},
+		{"1.00", "USD", "USDT", "1", RoundDown, "1.000000", false},
+		{"-10.00", "USD", "TWD", "32.5", RoundHalfUp, "-325.00", false},
+		{"1.00", "USD", "TWD", "0", RoundHalfUp, "", true},
+		{"1.00", "USD", "TWD", "-1", RoundHalfUp, "", true},
+		{"1.00", "USD", "TWD", "abc", RoundHalfUp, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := MustParse(tt.amount, tt.from).Convert(tt.to, tt.rate, tt.mode)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Convert(%s %s -> %s at %s) expected an error", tt.amount, tt.from, tt.to, tt.rate)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Convert(%s %s -> %s at %s) unexpected error: %v", tt.amount, tt.from, tt.to, tt.rate, err)
+			continue
+		}
+		if got.String() != tt.want || got.Currency() != tt.to {
+			t.Errorf("Convert(%s %s -> %s at %s) = %s %s, want %s %s", tt.amount, tt.from, tt.to, tt.rate, got, got.Currency(), tt.want, tt.to)
+		}
+	}
+}
+
+func TestConvertOverflow(t *testing.T) {
+	_, err := New(math.MaxInt64, "TWD").Convert("USD", "2", RoundDown)
+	if !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("Convert error = %v, want %v", err, ErrAmountOverflow)
+	}
+}
+
+func TestArithmeticOverflow(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func() Money
+	}{
+		{"add", func() Money { return New(math.MaxInt64, "TWD").Add(New(1, "TWD")) }},
+		{"add negative", func() Money { return New(math.MinInt64, "TWD").Add(New(-1, "TWD")) }},
+		{"sub", func() Money { return New(math.MinInt64, "TWD").Sub(New(1, "TWD")) }},
+		{"sub negative", func() Money { return New(math.MaxInt64, "TWD").Sub(New(-1, "TWD")) }},
+		{"mul", func() Money { return New(math.MaxInt64, "TWD").Mul(2, RoundDown) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrAmountOverflow) {
+					t.Errorf("panic = %v, want %v", err, ErrAmountOverflow)
+				}
+			}()
+			tt.op()
+		})
+	}
+}
+
+func TestArithmeticLimits(t *testing.T) {
+	if got := New(math.MaxInt64-1, "TWD").Add(New(1, "TWD")); got.Minor() != math.MaxInt64 {
+		t.Errorf("Add = %d, want %d", got.Minor(), int64(math.MaxInt64))
+	}
+	if got := New(math.MinInt64+1, "TWD").Sub(New(1, "TWD")); got.Minor() != math.MinInt64 {
+		t.Errorf("Sub = %d, want %d", got.Minor(), int64(math.MinInt64))
+	}
+	if got := New(-5, "TWD").Sub(New(-10, "TWD")); got.Minor() != 5 {
+		t.Errorf("Sub = %d, want 5", got.Minor())
+	}
+}